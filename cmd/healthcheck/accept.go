@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	MediaTypeJson       = "application/json"
+	MediaTypeHealthJson = "application/health+json"
+	MediaTypeText       = "text/plain"
+	MediaTypeHtml       = "text/html"
+)
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+	// specificity is 0 for */*, 1 for type/* and 2 for type/subtype
+	specificity int
+}
+
+func (mr mediaRange) matches(mediaType string) bool {
+	switch mr.specificity {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, strings.TrimSuffix(mr.mediaType, "*"))
+	default:
+		return mr.mediaType == mediaType
+	}
+}
+
+// parseAccept skips the ranges, which are malformed or have an invalid quality
+func parseAccept(header string) []mediaRange {
+	var result []mediaRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		slash := strings.Index(mediaType, "/")
+		if slash <= 0 || slash == len(mediaType)-1 {
+			continue
+		}
+		accepted := mediaRange{mediaType: mediaType, quality: 1, specificity: 2}
+		if mediaType == "*/*" {
+			accepted.specificity = 0
+		} else if strings.HasSuffix(mediaType, "/*") {
+			accepted.specificity = 1
+		}
+		valid := true
+		for _, param := range params[1:] {
+			name, value := param, ""
+			if eq := strings.Index(param, "="); eq >= 0 {
+				name, value = param[:eq], param[eq+1:]
+			}
+			if strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			quality, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || quality < 0 || quality > 1 {
+				valid = false
+				break
+			}
+			accepted.quality = quality
+		}
+		if valid {
+			result = append(result, accepted)
+		}
+	}
+	return result
+}
+
+// negotiate picks the offer of the highest quality, which the most specific matching range defines,
+// the earlier offers win the ties and an absent header accepts the first offer
+func negotiate(header string, offers []string) (string, bool) {
+	if strings.TrimSpace(header) == "" {
+		return offers[0], true
+	}
+	ranges := parseAccept(header)
+	best, bestQuality := "", 0.0
+	for _, offer := range offers {
+		quality, specificity := 0.0, -1
+		for _, accepted := range ranges {
+			if accepted.specificity > specificity && accepted.matches(offer) {
+				quality, specificity = accepted.quality, accepted.specificity
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best, bestQuality > 0
+}
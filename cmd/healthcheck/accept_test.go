@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{MediaTypeJson, MediaTypeHealthJson, MediaTypeText, MediaTypeHtml}
+	for _, testCase := range []struct {
+		header   string
+		expected string
+		ok       bool
+	}{
+		{"", MediaTypeJson, true},
+		{"*/*", MediaTypeJson, true},
+		{"application/json", MediaTypeJson, true},
+		{"application/json, */*", MediaTypeJson, true},
+		{"application/health+json", MediaTypeHealthJson, true},
+		{"Text/Plain", MediaTypeText, true},
+		{"text/*", MediaTypeText, true},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", MediaTypeHtml, true},
+		{"application/json;q=0.5, text/plain", MediaTypeText, true},
+		{"application/json;q=0.5, text/plain;q=0.5", MediaTypeJson, true},
+		{"*/*;q=0.1, application/json;q=0", MediaTypeHealthJson, true},
+		{"text/plain; charset=utf-8; q=0.3, text/html; level=1", MediaTypeHtml, true},
+		{"application/xml", "", false},
+		{"application/json;q=0", "", false},
+		{"application/json;q=2", "", false},
+		{"application/json;q=high, text/plain", MediaTypeText, true},
+		{"garbage, /json, application/", "", false},
+	} {
+		mediaType, ok := negotiate(testCase.header, offers)
+		if mediaType != testCase.expected || ok != testCase.ok {
+			t.Errorf("Unexpected media type '%s' (%v) for '%s', expected='%s' (%v)", mediaType, ok, testCase.header, testCase.expected, testCase.ok)
+		}
+	}
+}
@@ -1,103 +1,270 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/sirupsen/logrus"
-	"net/http"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 )
 
 type Application struct {
-	lifecycle     []Lifecycle
-	logger        *logrus.Logger
-	interruptions chan os.Signal
+	lifecycle       []Lifecycle
+	server          *Server
+	logger          *logrus.Logger
+	loggers         *Loggers
+	interruptions   chan os.Signal
+	hangups         chan os.Signal
+	gracePeriod     time.Duration
+	shutdown        context.Context
+	cancelShutdown  context.CancelFunc
+	loader          func() (*Config, error)
+	mutex           sync.Mutex
+	config          *Config
+	clientFactory   *HttpClientFactory
+	services        *serviceSet
+	healthHandler   *HealthHandler
+	probeHandlers   *ProbeHandlers
+	detailsHandler  *DetailsHandler
+	metricsHandler  *MetricsHandler
+	logLevelHandler *LogLevelHandler
+	historyHandler  *HistoryHandler
+	notifier        *Notifier
+	scheduler       *CronScheduler
 }
 
 func MakeApplication(config *Config) (*Application, error) {
 	logger := logrus.StandardLogger()
-	logger.SetFormatter(&JavaFormatter{})
+	formatter, err := MakeLogFormatter(config.Logging.Format)
+	if err != nil {
+		return nil, fmt.Errorf("can not make log formatter: %w", err)
+	}
+	logger.SetFormatter(formatter)
 	logger.Infof("started application with log level '%s'", logger.GetLevel())
 	logger.Infof("configuration:\n%s", config.AsJson())
-	logLevel, err := logrus.ParseLevel(config.Logging.Level.Root)
-	if err != nil {
-		return nil, fmt.Errorf("can not parse log level: %w", err)
+	loggers := MakeLoggers(logger)
+	if err := loggers.Configure(config.Logging); err != nil {
+		return nil, err
 	}
-	logger.Infof("switching to log level '%s'", logLevel)
-	logger.SetLevel(logLevel)
+	logger.Infof("switched to log level '%s'", logger.GetLevel())
 
-	var fragileServices []FragileService
-	var geoService FragileService
-	var scheduler Lifecycle
+	clientFactory := MakeHttpClientFactory(config.HttpClient)
+	services := &serviceSet{}
+	var scheduler *CronScheduler
 	if config.Schedule.Enabled {
-		fragileServices, err = makeServiceList(config.FailureThreshold, config.ClientServices.Services, logger)
+		services, err = makeServiceSet(config, clientFactory, loggers.Component(ComponentChecks), services)
 		if err != nil {
-			return nil, fmt.Errorf("can not make service list: %w", err)
+			return nil, err
 		}
-		geoService, err = makeGeoService(config, logger)
+		scheduler, err = MakeScheduler(services.checks, loggers.Component(ComponentScheduler))
 		if err != nil {
-			return nil, fmt.Errorf("can not make geo-service: %w", err)
-		}
-		if geoService != nil {
-			fragileServices = append(fragileServices, geoService)
+			return nil, fmt.Errorf("can not make scheduler: %w", err)
 		}
-		delay := time.Duration(int(time.Millisecond) * config.Schedule.Delay)
-		scheduler, err = MakeScheduler(delay, toServices(fragileServices), logger)
 	}
+	fragileServices := services.list
 
+	critical, optional, rules := services.aggregate(fragileServices)
 	healthHandler, err := MakeHealthHandler(
 		config.Pod.Namespace,
-		toFragiles(fragileServices),
-		geoService)
+		critical,
+		optional,
+		rules)
 	if err != nil {
 		return nil, fmt.Errorf("can not make request handler: %w", err)
 	}
-	lifecycle := []Lifecycle{MakeServer(config.Server.Port, healthHandler, logger)}
+	probeHandlers, err := MakeProbeHandlers(config.Pod.Namespace, services)
+	if err != nil {
+		return nil, fmt.Errorf("can not make probe handlers: %w", err)
+	}
+	detailsHandler := MakeDetailsHandler(config.Pod.Namespace, toReporters(fragileServices))
+	metricsHandler := MakeMetricsHandler(healthHandler)
+	for _, fragileService := range fragileServices {
+		metricsHandler.Register(fragileService, services.identities[fragileService])
+	}
+	historyHandler := MakeHistoryHandler(config.Pod.Namespace, config.History.Size)
+	for _, fragileService := range fragileServices {
+		historyHandler.Register(fragileService, services.identities[fragileService])
+	}
+	notifier, err := MakeNotifier(
+		config.Pod.Namespace,
+		config.Notifications,
+		clientFactory.MakeClient(config.Notifications.Timeouts),
+		loggers.Component(ComponentNotifier))
+	if err != nil {
+		return nil, fmt.Errorf("can not make notifier: %w", err)
+	}
+	notifier.Update(fragileServices, services.identities)
+	server, err := MakeServer(config.Server, loggers.Component(ComponentServer))
+	if err != nil {
+		return nil, fmt.Errorf("can not make server: %w", err)
+	}
+	// the probes stay open for kubelet
+	server.Handle("/health", healthHandler)
+	server.Handle("/livez", probeHandlers.Liveness)
+	server.Handle("/readyz", probeHandlers.Readiness)
+	server.Handle("/startupz", probeHandlers.Startup)
+	server.Handle("/health/details", MakeAuthHandler(config.Server.Auth, detailsHandler))
+	server.Handle("/health/history", MakeAuthHandler(config.Server.Auth, historyHandler))
+	server.Handle("/metrics", MakeAuthHandler(config.Server.Auth, metricsHandler))
+	var logLevelHandler *LogLevelHandler
+	var adminServer *Server
+	if config.Admin.Enabled {
+		logLevelHandler = MakeLogLevelHandler(loggers, string(config.Admin.Token), logger)
+		if config.Admin.Address != "" {
+			adminServer = MakeServerOnAddress(config.Admin.Address, loggers.Component(ComponentServer))
+			adminServer.Handle("/admin/log-level", logLevelHandler)
+		} else {
+			server.Handle("/admin/log-level", logLevelHandler)
+		}
+	}
+	lifecycle := []Lifecycle{server}
+	if adminServer != nil {
+		lifecycle = append(lifecycle, adminServer)
+	}
 	if scheduler != nil {
 		lifecycle = append(lifecycle, scheduler)
 	}
+	if len(config.Notifications.Webhooks) > 0 {
+		lifecycle = append(lifecycle, notifier)
+	}
 	return &Application{
-		lifecycle:     lifecycle,
-		logger:        logger,
-		interruptions: make(chan os.Signal, 1),
+		lifecycle:       lifecycle,
+		server:          server,
+		logger:          logger,
+		loggers:         loggers,
+		interruptions:   make(chan os.Signal, 1),
+		hangups:         make(chan os.Signal, 1),
+		gracePeriod:     milliseconds(config.Shutdown.GracePeriod),
+		config:          config,
+		clientFactory:   clientFactory,
+		services:        services,
+		healthHandler:   healthHandler,
+		probeHandlers:   probeHandlers,
+		detailsHandler:  detailsHandler,
+		metricsHandler:  metricsHandler,
+		logLevelHandler: logLevelHandler,
+		historyHandler:  historyHandler,
+		notifier:        notifier,
+		scheduler:       scheduler,
 	}, nil
 }
 
-func makeGeoService(config *Config, logger *logrus.Logger) (FragileService, error) {
+func makeGeoService(config *Config, clientFactory *HttpClientFactory, logger logrus.FieldLogger) (FragileService, error) {
 	configGeo := config.Geo
 	if configGeo == nil {
 		return nil, nil
 	}
-	endpoint := fmt.Sprintf("%s:%d/health", configGeo.Service, configGeo.Port)
-	service, err := MakeSimpleService(endpoint, &http.Client{})
+	endpoint := geoEndpoint(configGeo)
+	service, err := MakeSimpleService(endpoint, clientFactory.MakeClient(configGeo.Timeouts))
 	if err != nil {
 		return nil, fmt.Errorf("can not make service for endpoint '%s': %w", endpoint, err)
 	}
 	loggingDecorator := MakeLoggingServiceDecorator(service, logger)
-	watchfulDecorator := MakeHopefulProxy(loggingDecorator, config.FailureThreshold)
+	watchfulDecorator := MakeHopefulProxy(loggingDecorator, config.FailureThreshold, config.SuccessThreshold, logger)
 	return watchfulDecorator, nil
 }
 
-func makeServiceList(threshold int, serviceDescriptions []ServiceDescription, logger logrus.FieldLogger) ([]FragileService, error) {
+func geoEndpoint(configGeo *GeoConfig) string {
+	return fmt.Sprintf("%s:%d/health", configGeo.Service, configGeo.Port)
+}
+
+func makeServiceList(failureThreshold int, successThreshold int, serviceDescriptions []ServiceDescription, clientFactory *HttpClientFactory, logger logrus.FieldLogger) ([]FragileService, error) {
 	var result []FragileService
 	for _, srvDesc := range serviceDescriptions {
-		//This will be used inside a service mesh, it should encrypt all communications
-		//goland:noinspection HttpUrlsUsage
-		endpoint := fmt.Sprintf("http://%s:%d%s", srvDesc.Name, srvDesc.Port, srvDesc.Path)
-		actuatorService, err := MakeSimpleService(endpoint, &http.Client{})
+		service, err := makeService(srvDesc, clientFactory)
 		if err != nil {
-			return nil, fmt.Errorf("can not make service for endpoint '%s', %w", endpoint, err)
+			return nil, err
+		}
+		loggingDecorator := MakeLoggingServiceDecorator(service, logger)
+		threshold := failureThreshold
+		if srvDesc.FailureThreshold > 0 {
+			threshold = srvDesc.FailureThreshold
 		}
-		loggingDecorator := MakeLoggingServiceDecorator(actuatorService, logger)
-		watchfulDecorator := MakeHopefulProxy(loggingDecorator, threshold)
+		recoveryThreshold := successThreshold
+		if srvDesc.SuccessThreshold > 0 {
+			recoveryThreshold = srvDesc.SuccessThreshold
+		}
+		watchfulDecorator := MakeHopefulProxy(loggingDecorator, threshold, recoveryThreshold, logger)
 		result = append(result, watchfulDecorator)
 	}
 	return result, nil
 }
 
+// serviceEndpoint is the url of an http service and the address of the other services
+func serviceEndpoint(srvDesc ServiceDescription) string {
+	if srvDesc.Type != "" && srvDesc.Type != ServiceTypeHttp {
+		return net.JoinHostPort(srvDesc.Name, strconv.Itoa(srvDesc.Port))
+	}
+	// plain http is the default, inside a service mesh the mesh encrypts all communications
+	scheme := srvDesc.Scheme
+	if scheme == "" {
+		scheme = SchemeHttp
+	}
+	return fmt.Sprintf("%s://%s:%d%s", scheme, srvDesc.Name, srvDesc.Port, srvDesc.Path)
+}
+
+func makeService(srvDesc ServiceDescription, clientFactory *HttpClientFactory) (Service, error) {
+	switch srvDesc.Type {
+	case "", ServiceTypeHttp:
+		endpoint := serviceEndpoint(srvDesc)
+		statuses, err := ParseStatusRanges(srvDesc.AcceptedStatuses)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse accepted statuses for endpoint '%s': %w", endpoint, err)
+		}
+		var assertions []BodyAssertion
+		for _, assertionDesc := range srvDesc.Assertions {
+			assertion, err := MakeBodyAssertion(assertionDesc)
+			if err != nil {
+				return nil, fmt.Errorf("can not make assertion for endpoint '%s': %w", endpoint, err)
+			}
+			assertions = append(assertions, assertion)
+		}
+		tlsConfig, err := MakeTlsConfig(srvDesc.Tls)
+		if err != nil {
+			return nil, fmt.Errorf("can not make tls configuration for endpoint '%s': %w", endpoint, err)
+		}
+		client := clientFactory.MakeTlsClient(srvDesc.Timeouts, tlsConfig)
+		if srvDesc.FollowRedirects != nil && !*srvDesc.FollowRedirects {
+			client.CheckRedirect = doNotFollowRedirects
+		}
+		service, err := MakeSimpleServiceWithOptions(endpoint, client, SimpleServiceOptions{
+			Method:      srvDesc.Method,
+			Headers:     srvDesc.Headers,
+			Body:        srvDesc.Body,
+			Statuses:    statuses,
+			Assertions:  assertions,
+			MaxBodySize: srvDesc.MaxBodySize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("can not make service for endpoint '%s', %w", endpoint, err)
+		}
+		return service, nil
+	case ServiceTypeTcp:
+		address := serviceEndpoint(srvDesc)
+		timeout := milliseconds(clientFactory.Timeouts(srvDesc.Timeouts).Total)
+		return MakeTcpService(address, srvDesc.Expect, clientFactory.MakeDialer(srvDesc.Timeouts), timeout), nil
+	case ServiceTypeCertificate:
+		address := serviceEndpoint(srvDesc)
+		tlsConfig, err := MakeTlsConfig(srvDesc.Tls)
+		if err != nil {
+			return nil, fmt.Errorf("can not make tls configuration for address '%s': %w", address, err)
+		}
+		window := srvDesc.ExpiryWindowDays
+		if window == 0 {
+			window = defaultExpiryWindowDays
+		}
+		timeout := milliseconds(clientFactory.Timeouts(srvDesc.Timeouts).Total)
+		return MakeCertificateService(address, tlsConfig, clientFactory.MakeDialer(srvDesc.Timeouts), timeout, time.Duration(window)*24*time.Hour), nil
+	default:
+		return nil, fmt.Errorf("unknown type '%s' of service '%s'", srvDesc.Type, srvDesc.Name)
+	}
+}
+
 func toFragiles(fragileServices []FragileService) []Fragile {
 	var fragiles []Fragile
 	for _, fragileService := range fragileServices {
@@ -106,6 +273,14 @@ func toFragiles(fragileServices []FragileService) []Fragile {
 	return fragiles
 }
 
+func toReporters(fragileServices []FragileService) []Reporter {
+	var reporters []Reporter
+	for _, fragileService := range fragileServices {
+		reporters = append(reporters, fragileService)
+	}
+	return reporters
+}
+
 func toServices(fragileServices []FragileService) []Service {
 	var services []Service
 	for _, fragileService := range fragileServices {
@@ -120,31 +295,41 @@ func (application *Application) Run() {
 	}
 
 	wg := application.setUpInterruptHandler()
+	if application.loader != nil {
+		application.setUpHangupHandler()
+	}
 
 	wg.Wait()
 
 	for _, lifecycle := range application.lifecycle {
-		err := lifecycle.AwaitShutdown()
+		err := lifecycle.AwaitShutdown(application.shutdown)
 		if err != nil {
 			application.logger.Error(err)
 		}
 	}
+	application.cancelShutdown()
+	application.logger.Info("application stopped")
 }
 
+// setUpInterruptHandler stops the components in the reverse order of their start,
+// so that no new checks are started while the http server drains its connections
 func (application *Application) setUpInterruptHandler() *sync.WaitGroup {
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
-	signal.Notify(application.interruptions, syscall.SIGINT)
+	signal.Notify(application.interruptions, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		sig := <-application.interruptions
-		application.logger.Infof("received '%s' signal", sig)
-		wg.Done()
-		for _, lifecycle := range application.lifecycle {
-			err := lifecycle.Shutdown()
+		application.logger.Infof("received '%s' signal, shutting down within %s", sig, application.gracePeriod)
+		ctx, cancel := context.WithTimeout(context.Background(), application.gracePeriod)
+		application.shutdown, application.cancelShutdown = ctx, cancel
+		for i := len(application.lifecycle) - 1; i >= 0; i-- {
+			lifecycle := application.lifecycle[i]
+			err := lifecycle.Shutdown(ctx)
 			if err != nil {
 				application.logger.Errorf("'%s' shutdown failed: '%s'", lifecycle, err)
 			}
 		}
+		wg.Done()
 	}()
 	return wg
 }
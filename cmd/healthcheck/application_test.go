@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"syscall"
 	"testing"
@@ -13,9 +15,13 @@ import (
 
 type fragileServiceStub struct{}
 
-func (stub *fragileServiceStub) IsOk() bool    { return true }
-func (stub *fragileServiceStub) Check() error  { return nil }
-func (stub *fragileServiceStub) Print() string { return "fragile service" }
+func (stub *fragileServiceStub) IsOk() bool                  { return true }
+func (stub *fragileServiceStub) Check(context.Context) error { return nil }
+func (stub *fragileServiceStub) Print() string               { return "fragile service" }
+func (stub *fragileServiceStub) Report() ServiceReport {
+	return ServiceReport{Name: stub.Print(), IsOk: stub.IsOk()}
+}
+func (stub *fragileServiceStub) AddObserver(CheckObserver) {}
 
 func TestMakeApplication(t *testing.T) {
 	application, err := MakeApplication(&Config{
@@ -28,7 +34,73 @@ func TestMakeApplication(t *testing.T) {
 	if len(application.lifecycle) != 1 {
 		t.Errorf("Unexpected number of lifecycle components %d", len(application.lifecycle))
 	}
-	http.DefaultServeMux = new(http.ServeMux)
+}
+
+func TestMakeApplication_admin(t *testing.T) {
+	application, err := MakeApplication(&Config{
+		Logging: LoggingConfig{Level: ConfigLoggingLevel{Root: "info"}},
+		Server:  ServerConfig{Port: 8080},
+		Admin:   AdminConfig{Enabled: true, Address: "127.0.0.1:8081"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	if len(application.lifecycle) != 2 || application.logLevelHandler == nil {
+		t.Errorf("Unexpected number of lifecycle components %d", len(application.lifecycle))
+	}
+	if _, pattern := application.server.routes.Handler(httptest.NewRequest(http.MethodGet, "/admin/log-level", nil)); pattern != "" {
+		t.Errorf("Unexpected admin route on the main server")
+	}
+
+	application, err = MakeApplication(&Config{
+		Logging: LoggingConfig{Level: ConfigLoggingLevel{Root: "info"}},
+		Server:  ServerConfig{Port: 8080},
+		Admin:   AdminConfig{Enabled: true, Token: "secret"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	if len(application.lifecycle) != 1 {
+		t.Errorf("Unexpected number of lifecycle components %d", len(application.lifecycle))
+	}
+	if _, pattern := application.server.routes.Handler(httptest.NewRequest(http.MethodGet, "/admin/log-level", nil)); pattern != "/admin/log-level" {
+		t.Errorf("Unexpected pattern '%s' of the admin route", pattern)
+	}
+}
+
+func TestMakeApplication_auth(t *testing.T) {
+	application, err := MakeApplication(&Config{
+		Logging: LoggingConfig{Level: ConfigLoggingLevel{Root: "info"}},
+		Server:  ServerConfig{Port: 8080, Auth: ServerAuthConfig{Token: "secret"}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	for path, expected := range map[string]int{
+		"/health":         http.StatusOK,
+		"/livez":          http.StatusOK,
+		"/health/details": http.StatusUnauthorized,
+		"/health/history": http.StatusUnauthorized,
+		"/metrics":        http.StatusUnauthorized,
+	} {
+		request := httptest.NewRequest(http.MethodGet, path, nil)
+		request.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		application.server.ServeHTTP(rr, request)
+		if rr.Code != expected {
+			t.Errorf("Unexpected status %d of '%s', expected=%d", rr.Code, path, expected)
+		}
+	}
+}
+
+func TestMakeApplication_invalidServerCertificate(t *testing.T) {
+	_, err := MakeApplication(&Config{
+		Logging: LoggingConfig{Level: ConfigLoggingLevel{Root: "info"}},
+		Server:  ServerConfig{Port: 8080, Tls: ServerTlsConfig{CertFile: "missing.crt", KeyFile: "missing.key"}},
+	})
+	if err == nil {
+		t.Errorf("Unexpected nil error")
+	}
 }
 
 func TestMakeApplication_schedulingEnabled(t *testing.T) {
@@ -68,7 +140,16 @@ func TestMakeApplication_schedulingEnabled(t *testing.T) {
 	if len(application.lifecycle) != 2 {
 		t.Errorf("Unexpected number of lifecycle components %d", len(application.lifecycle))
 	}
-	http.DefaultServeMux = new(http.ServeMux)
+	metrics := application.metricsHandler.services.snapshot()
+	histories := application.historyHandler.services.snapshot()
+	for i, expected := range []string{"name", RuleGeo} {
+		if name := metrics[i].(*serviceMetrics).identity.name; name != expected {
+			t.Errorf("Unexpected metrics label '%s', expected='%s'", name, expected)
+		}
+		if name := histories[i].(*serviceHistory).identity.name; name != expected {
+			t.Errorf("Unexpected history name '%s', expected='%s'", name, expected)
+		}
+	}
 }
 
 func TestMakeApplication_invalidGeo(t *testing.T) {
@@ -82,9 +163,6 @@ func TestMakeApplication_invalidGeo(t *testing.T) {
 	}
 
 	_, err := MakeApplication(&config)
-	defer func() {
-		http.DefaultServeMux = new(http.ServeMux)
-	}()
 	if err == nil {
 		t.Fatal("Unexpected nil error")
 	}
@@ -112,9 +190,6 @@ func TestMakeApplication_invalidServices(t *testing.T) {
 	}
 
 	_, err := MakeApplication(&config)
-	defer func() {
-		http.DefaultServeMux = new(http.ServeMux)
-	}()
 	if err == nil {
 		t.Fatal("Unexpected nil error")
 	}
@@ -137,7 +212,6 @@ func TestMakeApplication_invalidLogLevelInConfiguration(t *testing.T) {
 	if application != nil {
 		t.Errorf("Unexpected non nil application")
 	}
-	http.DefaultServeMux = new(http.ServeMux)
 }
 
 func TestApplication_toFragiles(t *testing.T) {
@@ -148,6 +222,14 @@ func TestApplication_toFragiles(t *testing.T) {
 	}
 }
 
+func TestApplication_toReporters(t *testing.T) {
+	fragileServices := []FragileService{&fragileServiceStub{}}
+	reporters := toReporters(fragileServices)
+	if len(reporters) != 1 {
+		t.Errorf("Unexpected number of reporters in result: %d", len(reporters))
+	}
+}
+
 func TestApplication_toServices(t *testing.T) {
 	fragileServices := []FragileService{&fragileServiceStub{}}
 	services := toServices(fragileServices)
@@ -162,11 +244,11 @@ type lifecycleMock struct {
 func (l *lifecycleMock) StartAsync() {
 }
 
-func (l *lifecycleMock) Shutdown() error {
+func (l *lifecycleMock) Shutdown(context.Context) error {
 	return fmt.Errorf("test shutdown error")
 }
 
-func (l *lifecycleMock) AwaitShutdown() error {
+func (l *lifecycleMock) AwaitShutdown(context.Context) error {
 	return fmt.Errorf("test await shutdown error")
 }
 
@@ -182,7 +264,52 @@ func TestApplication_setUpInterruptionHandler(t *testing.T) {
 	wg := application.setUpInterruptHandler()
 	sigs <- syscall.SIGINT
 	wg.Wait()
-	http.DefaultServeMux = new(http.ServeMux)
+}
+
+type recordingLifecycle struct {
+	name    string
+	stopped *[]string
+}
+
+func (l *recordingLifecycle) StartAsync() {}
+
+func (l *recordingLifecycle) Shutdown(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return fmt.Errorf("shutdown context should have a deadline")
+	}
+	*l.stopped = append(*l.stopped, l.name)
+	return nil
+}
+
+func (l *recordingLifecycle) AwaitShutdown(context.Context) error { return nil }
+
+func TestApplication_setUpInterruptionHandler_Sigterm(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	var stopped []string
+	done := make(chan bool)
+	application := Application{
+		lifecycle: []Lifecycle{
+			&recordingLifecycle{name: "server", stopped: &stopped},
+			&recordingLifecycle{name: "scheduler", stopped: &stopped},
+		},
+		logger:        logger,
+		interruptions: make(chan os.Signal, 1),
+		gracePeriod:   time.Second,
+	}
+	go func() {
+		application.Run()
+		done <- true
+	}()
+	application.interruptions <- syscall.SIGTERM
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Application should stop on SIGTERM")
+	}
+	if len(stopped) != 2 || stopped[0] != "scheduler" || stopped[1] != "server" {
+		t.Errorf("Unexpected shutdown order: %v", stopped)
+	}
 }
 
 func TestApplication_makeServiceList(t *testing.T) {
@@ -195,7 +322,7 @@ func TestApplication_makeServiceList(t *testing.T) {
 	}
 	logger := log.New()
 	logger.SetOutput(bytes.NewBufferString(""))
-	list, err := makeServiceList(3, serviceDescriptions, logger)
+	list, err := makeServiceList(3, 1, serviceDescriptions, MakeHttpClientFactory(HttpClientConfig{}), logger)
 	if err != nil {
 		t.Errorf("Unexpected error: %s", err.Error())
 	}
@@ -217,6 +344,185 @@ func TestApplication_makeServiceList(t *testing.T) {
 	}
 }
 
+func TestApplication_makeServiceList_httpOptions(t *testing.T) {
+	followRedirects := false
+	serviceDescriptions := []ServiceDescription{
+		{
+			Type:             ServiceTypeHttp,
+			Name:             "service",
+			Port:             8080,
+			Path:             "/health",
+			Method:           http.MethodHead,
+			Headers:          map[string]string{"x-probe": "1"},
+			AcceptedStatuses: []string{"2xx", "301"},
+			FollowRedirects:  &followRedirects,
+		},
+	}
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	list, err := makeServiceList(3, 1, serviceDescriptions, MakeHttpClientFactory(HttpClientConfig{}), logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	loggingDecorator := list[0].(*HopefulProxy).backend.(*LoggingServiceDecorator)
+	simpleService := loggingDecorator.backend.(*SimpleService)
+	if simpleService.options.Method != http.MethodHead {
+		t.Errorf("Unexpected method: %s", simpleService.options.Method)
+	}
+	if !simpleService.options.Statuses.Contains(204) || !simpleService.options.Statuses.Contains(301) {
+		t.Errorf("Unexpected accepted statuses: %v", simpleService.options.Statuses)
+	}
+	if simpleService.client.CheckRedirect == nil {
+		t.Errorf("Redirects should not be followed")
+	}
+}
+
+func TestApplication_makeServiceList_https(t *testing.T) {
+	serviceDescriptions := []ServiceDescription{{
+		Name:   "service",
+		Port:   8443,
+		Path:   "/health",
+		Scheme: SchemeHttps,
+		Tls:    TlsConfig{ServerName: "service.internal", InsecureSkipVerify: true},
+	}}
+	list, err := makeServiceList(3, 1, serviceDescriptions, MakeHttpClientFactory(HttpClientConfig{}), silentLogger())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	simpleService := list[0].(*HopefulProxy).backend.(*LoggingServiceDecorator).backend.(*SimpleService)
+	if simpleService.endpoint != "https://service:8443/health" {
+		t.Errorf("Unexpected endpoint: %s", simpleService.endpoint)
+	}
+	tlsConfig := simpleService.client.Transport.(*http.Transport).TLSClientConfig
+	if tlsConfig == nil || tlsConfig.ServerName != "service.internal" || !tlsConfig.InsecureSkipVerify {
+		t.Errorf("Unexpected tls configuration: %+v", tlsConfig)
+	}
+}
+
+func TestApplication_makeServiceList_thresholdOverrides(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	list, err := makeServiceList(3, 1, []ServiceDescription{
+		{Name: "default", Port: 80},
+		{Name: "overridden", Port: 80, FailureThreshold: 5, SuccessThreshold: 2},
+	}, MakeHttpClientFactory(HttpClientConfig{}), logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	defaults := list[0].(*HopefulProxy)
+	if defaults.threshold != 3 || defaults.successThreshold != 1 {
+		t.Errorf("Unexpected default thresholds: %d, %d", defaults.threshold, defaults.successThreshold)
+	}
+	overridden := list[1].(*HopefulProxy)
+	if overridden.threshold != 5 || overridden.successThreshold != 2 {
+		t.Errorf("Unexpected overridden thresholds: %d, %d", overridden.threshold, overridden.successThreshold)
+	}
+}
+
+func TestApplication_makeServiceList_invalidStatuses(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	_, err := makeServiceList(3, 1, []ServiceDescription{{Name: "service", Port: 80, AcceptedStatuses: []string{"7xx"}}}, MakeHttpClientFactory(HttpClientConfig{}), logger)
+	if err == nil {
+		t.Fatal("Unexpected nil error")
+	}
+}
+
+func TestApplication_makeServiceList_invalidAssertion(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	_, err := makeServiceList(3, 1, []ServiceDescription{{
+		Name:       "service",
+		Port:       80,
+		Assertions: []BodyAssertionDescription{{Regex: "("}},
+	}}, MakeHttpClientFactory(HttpClientConfig{}), logger)
+	if err == nil {
+		t.Fatal("Unexpected nil error")
+	}
+}
+
+func TestApplication_makeServiceList_tcp(t *testing.T) {
+	serviceDescriptions := []ServiceDescription{
+		{
+			Type:   ServiceTypeTcp,
+			Name:   "postgres",
+			Port:   5432,
+			Expect: "banner",
+		},
+	}
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	list, err := makeServiceList(3, 1, serviceDescriptions, MakeHttpClientFactory(HttpClientConfig{}), logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	loggingDecorator := list[0].(*HopefulProxy).backend.(*LoggingServiceDecorator)
+	tcpService := loggingDecorator.backend.(*TcpService)
+	if tcpService.address != "postgres:5432" {
+		t.Errorf("Unexpected address: %s", tcpService.address)
+	}
+	if tcpService.expect != "banner" {
+		t.Errorf("Unexpected expected banner: %s", tcpService.expect)
+	}
+}
+
+func TestApplication_makeServiceList_certificate(t *testing.T) {
+	serviceDescriptions := []ServiceDescription{{Type: ServiceTypeCertificate, Name: "service", Port: 443}}
+	list, err := makeServiceList(3, 1, serviceDescriptions, MakeHttpClientFactory(HttpClientConfig{}), silentLogger())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	certificateService := list[0].(*HopefulProxy).backend.(*LoggingServiceDecorator).backend.(*CertificateService)
+	if certificateService.address != "service:443" || certificateService.tlsConfig.ServerName != "service" {
+		t.Errorf("Unexpected certificate service %s", certificateService.Print())
+	}
+	if certificateService.window != defaultExpiryWindowDays*24*time.Hour {
+		t.Errorf("Unexpected expiry window %s", certificateService.window)
+	}
+}
+
+func TestApplication_makeServiceList_unknownType(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	_, err := makeServiceList(3, 1, []ServiceDescription{{Type: "udp", Name: "dns", Port: 53}}, MakeHttpClientFactory(HttpClientConfig{}), logger)
+	if err == nil {
+		t.Fatal("Unexpected nil error")
+	}
+}
+
+func TestApplication_makeServiceSet_schedules(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	config := Config{
+		Schedule: ScheduleConfig{Enabled: true, Delay: 2000},
+		ClientServices: ClientServicesConfig{Services: []ServiceDescription{
+			{Name: "default", Port: 80},
+			{Name: "critical", Port: 80, Schedule: ServiceScheduleConfig{Interval: 1000, InitialDelay: 500, Jitter: 100}},
+			{Name: "expensive", Port: 80, Schedule: ServiceScheduleConfig{Cron: "* * * * *"}},
+		}},
+		Geo:              &GeoConfig{Service: "http://geo", Port: 80, Schedule: ServiceScheduleConfig{Interval: 60000}},
+		FailureThreshold: 1,
+	}
+	set, err := makeServiceSet(&config, MakeHttpClientFactory(HttpClientConfig{}), logger, &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	expected := []Schedule{
+		{Interval: 2 * time.Second},
+		{Interval: time.Second, InitialDelay: 500 * time.Millisecond, Jitter: 100 * time.Millisecond},
+		{Interval: 2 * time.Second, Cron: "* * * * *"},
+		{Interval: time.Minute},
+	}
+	if len(set.checks) != len(expected) {
+		t.Fatalf("Unexpected number of checks %d", len(set.checks))
+	}
+	for i, check := range set.checks {
+		if check.Schedule != expected[i] {
+			t.Errorf("Unexpected schedule %+v, expected=%+v", check.Schedule, expected[i])
+		}
+	}
+}
+
 func TestApplication_Lifecycle(t *testing.T) {
 	logger := log.New()
 	logger.SetOutput(bytes.NewBufferString(""))
@@ -230,7 +536,6 @@ func TestApplication_Lifecycle(t *testing.T) {
 		application.interruptions <- syscall.SIGINT
 	}()
 	application.Run()
-	http.DefaultServeMux = new(http.ServeMux)
 }
 
 func TestApplication_makeGeoService(t *testing.T) {
@@ -242,7 +547,7 @@ func TestApplication_makeGeoService(t *testing.T) {
 			Port:    80,
 		},
 		FailureThreshold: 3}
-	service, err := makeGeoService(&config, logger)
+	service, err := makeGeoService(&config, MakeHttpClientFactory(HttpClientConfig{}), logger)
 	if err != nil {
 		t.Fatalf("Unexpecgted error: %s", err.Error())
 	}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AuthHandler lets a request through to the backend if it carries the bearer token or the basic credentials
+type AuthHandler struct {
+	config  ServerAuthConfig
+	backend http.Handler
+}
+
+// MakeAuthHandler returns the backend as is when no credentials are configured
+func MakeAuthHandler(config ServerAuthConfig, backend http.Handler) http.Handler {
+	if config.Token == "" && config.Username == "" {
+		return backend
+	}
+	return &AuthHandler{config: config, backend: backend}
+}
+
+func secretEquals(actual string, expected Secret) bool {
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(expected)) == 1
+}
+
+// hasBearerToken accepts only the token with the Bearer scheme in the Authorization header
+func hasBearerToken(req *http.Request, token Secret) bool {
+	authorization := req.Header.Get("Authorization")
+	return strings.HasPrefix(authorization, "Bearer ") && secretEquals(strings.TrimPrefix(authorization, "Bearer "), token)
+}
+
+func (ah *AuthHandler) authorized(req *http.Request) bool {
+	if ah.config.Token != "" && hasBearerToken(req, ah.config.Token) {
+		return true
+	}
+	if ah.config.Username != "" {
+		username, password, ok := req.BasicAuth()
+		usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(ah.config.Username)) == 1
+		if ok && usernameMatches && secretEquals(password, ah.config.Password) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ah *AuthHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if !ah.authorized(req) {
+		if ah.config.Username != "" {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="healthcheck"`)
+		} else {
+			rw.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	ah.backend.ServeHTTP(rw, req)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMakeAuthHandler_NoCredentials(t *testing.T) {
+	backend := http.NotFoundHandler()
+	if handler := MakeAuthHandler(ServerAuthConfig{}, backend); handler == nil {
+		t.Errorf("Unexpected nil handler")
+	} else if _, ok := handler.(*AuthHandler); ok {
+		t.Errorf("Backend should not be guarded without credentials")
+	}
+}
+
+func TestAuthHandler_ServeHTTP(t *testing.T) {
+	backend := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+	both := ServerAuthConfig{Token: "token", Username: "user", Password: "password"}
+	for _, testCase := range []struct {
+		config        ServerAuthConfig
+		authorization func(req *http.Request)
+		expected      int
+	}{
+		{both, func(req *http.Request) {}, http.StatusUnauthorized},
+		{both, func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") }, http.StatusOK},
+		{both, func(req *http.Request) { req.Header.Set("Authorization", "Bearer wrong") }, http.StatusUnauthorized},
+		{both, func(req *http.Request) { req.Header.Set("Authorization", "token") }, http.StatusUnauthorized},
+		{both, func(req *http.Request) { req.SetBasicAuth("user", "password") }, http.StatusOK},
+		{both, func(req *http.Request) { req.SetBasicAuth("user", "wrong") }, http.StatusUnauthorized},
+		{both, func(req *http.Request) { req.SetBasicAuth("other", "password") }, http.StatusUnauthorized},
+		{ServerAuthConfig{Token: "token"}, func(req *http.Request) { req.SetBasicAuth("", "") }, http.StatusUnauthorized},
+		{ServerAuthConfig{Username: "user", Password: "password"}, func(req *http.Request) { req.Header.Set("Authorization", "Bearer ") }, http.StatusUnauthorized},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		testCase.authorization(request)
+		rr := httptest.NewRecorder()
+		MakeAuthHandler(testCase.config, backend).ServeHTTP(rr, request)
+		if rr.Code != testCase.expected {
+			t.Errorf("Unexpected status %d for '%s', expected=%d", rr.Code, request.Header.Get("Authorization"), testCase.expected)
+		}
+		if rr.Code == http.StatusUnauthorized && rr.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Challenge is missing for '%s'", request.Header.Get("Authorization"))
+		}
+	}
+}
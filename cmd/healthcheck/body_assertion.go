@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type BodyAssertion interface {
+	Assert(body []byte) error
+}
+
+func MakeBodyAssertion(description BodyAssertionDescription) (BodyAssertion, error) {
+	kinds := 0
+	for _, value := range []string{description.JsonPath, description.Contains, description.Regex} {
+		if value != "" {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return nil, errors.New("exactly one of json-path, contains or regex must be set")
+	}
+	switch {
+	case description.JsonPath != "":
+		path, err := parseJsonPath(description.JsonPath)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonPathAssertion{expression: description.JsonPath, path: path, expected: description.Equals}, nil
+	case description.Contains != "":
+		return &containsAssertion{substring: description.Contains}, nil
+	default:
+		pattern, err := regexp.Compile(description.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("can not compile regex '%s': %w", description.Regex, err)
+		}
+		return &regexAssertion{pattern: pattern}, nil
+	}
+}
+
+type containsAssertion struct {
+	substring string
+}
+
+func (a *containsAssertion) Assert(body []byte) error {
+	if !bytes.Contains(body, []byte(a.substring)) {
+		return fmt.Errorf("body does not contain '%s'", a.substring)
+	}
+	return nil
+}
+
+type regexAssertion struct {
+	pattern *regexp.Regexp
+}
+
+func (a *regexAssertion) Assert(body []byte) error {
+	if !a.pattern.Match(body) {
+		return fmt.Errorf("body does not match '%s'", a.pattern)
+	}
+	return nil
+}
+
+type jsonPathAssertion struct {
+	expression string
+	path       []interface{}
+	expected   string
+}
+
+func (a *jsonPathAssertion) Assert(body []byte) error {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return fmt.Errorf("body is not valid json: %w", err)
+	}
+	value := document
+	for _, step := range a.path {
+		switch key := step.(type) {
+		case string:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s is not found in body", a.expression)
+			}
+			if value, ok = object[key]; !ok {
+				return fmt.Errorf("%s is not found in body", a.expression)
+			}
+		case int:
+			array, ok := value.([]interface{})
+			if !ok || key >= len(array) {
+				return fmt.Errorf("%s is not found in body", a.expression)
+			}
+			value = array[key]
+		}
+	}
+	actual := jsonValueAsString(value)
+	if actual != a.expected {
+		return fmt.Errorf("%s is '%s', expected '%s'", a.expression, actual, a.expected)
+	}
+	return nil
+}
+
+func jsonValueAsString(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// parseJsonPath supports the dot-notation subset of JSONPath: $.key.nested[0].key
+func parseJsonPath(expression string) ([]interface{}, error) {
+	if !strings.HasPrefix(expression, "$") {
+		return nil, fmt.Errorf("json path '%s' must start with '$'", expression)
+	}
+	var path []interface{}
+	rest := expression[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("json path '%s' contains an empty key", expression)
+			}
+			path = append(path, key)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("json path '%s' contains unterminated index", expression)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("json path '%s' contains invalid index '%s'", expression, rest[1:end])
+			}
+			path = append(path, index)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("json path '%s' is malformed at '%s'", expression, rest)
+		}
+	}
+	return path, nil
+}
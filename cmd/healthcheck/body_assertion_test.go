@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestMakeBodyAssertion_Invalid(t *testing.T) {
+	for _, description := range []BodyAssertionDescription{
+		{},
+		{JsonPath: "$.status", Contains: "UP"},
+		{JsonPath: "status", Equals: "UP"},
+		{JsonPath: "$.items[x]", Equals: "UP"},
+		{JsonPath: "$..status", Equals: "UP"},
+		{Regex: "("},
+	} {
+		_, err := MakeBodyAssertion(description)
+		if err == nil {
+			t.Errorf("Error is expected for %+v", description)
+		}
+	}
+}
+
+func TestJsonPathAssertion(t *testing.T) {
+	body := []byte(`{"status":"UP","components":{"db":{"status":"DOWN","details":[{"count":3}]}},"ready":true}`)
+	for _, testCase := range []struct {
+		path     string
+		expected string
+		message  string
+	}{
+		{path: "$.status", expected: "UP"},
+		{path: "$.ready", expected: "true"},
+		{path: "$.components.db.details[0].count", expected: "3"},
+		{path: "$.components.db.status", expected: "UP", message: "$.components.db.status is 'DOWN', expected 'UP'"},
+		{path: "$.components.cache.status", expected: "UP", message: "$.components.cache.status is not found in body"},
+		{path: "$.components.db.details[1]", expected: "UP", message: "$.components.db.details[1] is not found in body"},
+	} {
+		assertion, err := MakeBodyAssertion(BodyAssertionDescription{JsonPath: testCase.path, Equals: testCase.expected})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+		err = assertion.Assert(body)
+		if testCase.message == "" && err != nil {
+			t.Errorf("Unexpected error for '%s': %s", testCase.path, err.Error())
+		}
+		if testCase.message != "" && (err == nil || err.Error() != testCase.message) {
+			t.Errorf("Unexpected error for '%s': %v", testCase.path, err)
+		}
+	}
+}
+
+func TestJsonPathAssertion_InvalidJson(t *testing.T) {
+	assertion, _ := MakeBodyAssertion(BodyAssertionDescription{JsonPath: "$.status", Equals: "UP"})
+	err := assertion.Assert([]byte("UP"))
+	if err == nil {
+		t.Errorf("Error is expected for invalid json")
+	}
+}
+
+func TestContainsAssertion(t *testing.T) {
+	assertion, _ := MakeBodyAssertion(BodyAssertionDescription{Contains: "UP"})
+	if err := assertion.Assert([]byte(`{"status":"UP"}`)); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	err := assertion.Assert([]byte(`{"status":"DOWN"}`))
+	if err == nil || err.Error() != "body does not contain 'UP'" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestRegexAssertion(t *testing.T) {
+	assertion, _ := MakeBodyAssertion(BodyAssertionDescription{Regex: `"status"\s*:\s*"UP"`})
+	if err := assertion.Assert([]byte(`{"status": "UP"}`)); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	err := assertion.Assert([]byte(`{"status": "OUT_OF_SERVICE"}`))
+	if err == nil || err.Error() != `body does not match '"status"\s*:\s*"UP"'` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertificateReloader serves the key pair from the files and loads it again once either of the files changes
+type CertificateReloader struct {
+	certFile    string
+	keyFile     string
+	logger      logrus.FieldLogger
+	mutex       sync.Mutex
+	certificate *tls.Certificate
+	modified    [2]time.Time
+}
+
+func MakeCertificateReloader(certFile string, keyFile string, logger logrus.FieldLogger) (*CertificateReloader, error) {
+	cr := &CertificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   logger,
+	}
+	modified, err := cr.modificationTimes()
+	if err != nil {
+		return nil, err
+	}
+	if err := cr.load(); err != nil {
+		return nil, err
+	}
+	cr.modified = modified
+	return cr, nil
+}
+
+func (cr *CertificateReloader) modificationTimes() ([2]time.Time, error) {
+	var result [2]time.Time
+	for i, file := range []string{cr.certFile, cr.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return result, fmt.Errorf("can not stat '%s': %w", file, err)
+		}
+		result[i] = info.ModTime()
+	}
+	return result, nil
+}
+
+func (cr *CertificateReloader) load() error {
+	certificate, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return fmt.Errorf("can not load server certificate: %w", err)
+	}
+	cr.certificate = &certificate
+	return nil
+}
+
+// GetCertificate keeps serving the previous key pair if the changed files can not be loaded
+func (cr *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mutex.Lock()
+	defer cr.mutex.Unlock()
+	modified, err := cr.modificationTimes()
+	if err != nil {
+		cr.logger.Warnf("keeping the server certificate: %s", err.Error())
+		return cr.certificate, nil
+	}
+	if modified != cr.modified {
+		// a failed load is not retried until the files change again, e.g. when the second file of the pair is written
+		cr.modified = modified
+		if err := cr.load(); err != nil {
+			cr.logger.Warnf("keeping the server certificate: %s", err.Error())
+		} else {
+			cr.logger.Infof("reloaded the server certificate from '%s'", cr.certFile)
+		}
+	}
+	return cr.certificate, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func getCertificate(t *testing.T, reloader *CertificateReloader) []byte {
+	certificate, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	return certificate.Certificate[0]
+}
+
+func touch(t *testing.T, at time.Time, files ...string) {
+	for _, file := range files {
+		if err := os.Chtimes(file, at, at); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+	}
+}
+
+func TestCertificateReloader_GetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := makeSelfSignedCertificate(t, dir, "localhost", time.Now().Add(time.Hour))
+	reloader, err := MakeCertificateReloader(certFile, keyFile, silentLogger())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	first := getCertificate(t, reloader)
+	if !bytes.Equal(first, getCertificate(t, reloader)) {
+		t.Errorf("Certificate should not change while the files do not change")
+	}
+
+	makeSelfSignedCertificate(t, dir, "localhost", time.Now().Add(2*time.Hour))
+	touch(t, time.Now().Add(time.Minute), certFile, keyFile)
+	second := getCertificate(t, reloader)
+	if bytes.Equal(first, second) {
+		t.Errorf("Certificate should be reloaded after the files change")
+	}
+
+	if err := ioutil.WriteFile(certFile, []byte("garbage"), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	touch(t, time.Now().Add(2*time.Minute), certFile)
+	if !bytes.Equal(second, getCertificate(t, reloader)) {
+		t.Errorf("Previous certificate should be kept when the files can not be loaded")
+	}
+}
+
+func TestMakeCertificateReloader_Missing(t *testing.T) {
+	_, err := MakeCertificateReloader("missing.crt", "missing.key", silentLogger())
+	if err == nil {
+		t.Errorf("Unexpected nil error")
+	}
+}
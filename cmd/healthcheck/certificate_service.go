@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net"
+	"sync"
+	"time"
+)
+
+const defaultExpiryWindowDays = 14
+
+// Expiring is implemented by the services, which know when the checked certificates expire
+type Expiring interface {
+	DaysToExpiry() (float64, bool)
+}
+
+// CertificateService inspects the peer chain without verifying it, trust is verified by https checks
+type CertificateService struct {
+	address   string
+	tlsConfig *tls.Config
+	dialer    *net.Dialer
+	timeout   time.Duration
+	window    time.Duration
+	name      string
+	mutex     sync.Mutex
+	days      float64
+	known     bool
+}
+
+func MakeCertificateService(address string, tlsConfig *tls.Config, dialer *net.Dialer, timeout time.Duration, window time.Duration) Service {
+	config := &tls.Config{}
+	if tlsConfig != nil {
+		config = tlsConfig.Clone()
+	}
+	if config.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err == nil {
+			config.ServerName = host
+		}
+	}
+	config.InsecureSkipVerify = true
+	return &CertificateService{
+		address:   address,
+		tlsConfig: config,
+		dialer:    dialer,
+		timeout:   timeout,
+		window:    window,
+		name:      fmt.Sprintf("certificate at '%s'", address),
+	}
+}
+
+func (srv *CertificateService) Print() string {
+	return srv.name
+}
+
+func (srv *CertificateService) Endpoint() string {
+	return srv.address
+}
+
+func (srv *CertificateService) DaysToExpiry() (float64, bool) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	return srv.days, srv.known
+}
+
+func (srv *CertificateService) Check(ctx context.Context) error {
+	if srv.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, srv.timeout)
+		defer cancel()
+	}
+	dialer := &tls.Dialer{NetDialer: srv.dialer, Config: srv.tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", srv.address)
+	if err != nil {
+		return classifyError(fmt.Errorf("can not make tls handshake: %w", err))
+	}
+	defer func(conn net.Conn) {
+		err := conn.Close()
+		if err != nil {
+			log.Warnf("could not close the connection: %s", err.Error())
+		}
+	}(conn)
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return &CertificateError{Err: fmt.Errorf("no certificates presented by '%s'", srv.address)}
+	}
+	now := time.Now()
+	expiring := chain[0]
+	for _, certificate := range chain[1:] {
+		if certificate.NotAfter.Before(expiring.NotAfter) {
+			expiring = certificate
+		}
+	}
+	remaining := expiring.NotAfter.Sub(now)
+	days := remaining.Hours() / 24
+	srv.mutex.Lock()
+	srv.days, srv.known = days, true
+	srv.mutex.Unlock()
+	if remaining <= 0 {
+		return &CertificateError{Err: fmt.Errorf("certificate '%s' of '%s' has expired %.1f days ago", expiring.Subject, srv.address, -days)}
+	}
+	if remaining < srv.window {
+		return &CertificateError{Err: fmt.Errorf("certificate '%s' of '%s' expires in %.1f days", expiring.Subject, srv.address, days)}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io/ioutil"
+	stdlog "log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func startCertificateServer(t *testing.T, notAfter time.Time) *httptest.Server {
+	certFile, keyFile := makeSelfSignedCertificate(t, t.TempDir(), "localhost", notAfter)
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	server.Config.ErrorLog = stdlog.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	return server
+}
+
+func makeTestCertificateService(server *httptest.Server, windowDays int) *CertificateService {
+	address := strings.TrimPrefix(server.URL, "https://")
+	window := time.Duration(windowDays) * 24 * time.Hour
+	return MakeCertificateService(address, nil, &net.Dialer{}, time.Second, window).(*CertificateService)
+}
+
+func TestCertificateService_Check(t *testing.T) {
+	server := startCertificateServer(t, time.Now().Add(5*24*time.Hour))
+	defer server.Close()
+
+	service := makeTestCertificateService(server, 1)
+	if _, known := service.DaysToExpiry(); known {
+		t.Errorf("Unexpected days to expiry before the check")
+	}
+	if err := service.Check(context.Background()); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+	days, known := service.DaysToExpiry()
+	if !known || days < 4.9 || days > 5 {
+		t.Errorf("Unexpected days to expiry %f", days)
+	}
+
+	service = makeTestCertificateService(server, 14)
+	err := service.Check(context.Background())
+	var certErr *CertificateError
+	if !errors.As(err, &certErr) || !strings.Contains(err.Error(), "expires in 5.0 days") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCertificateService_Check_Expired(t *testing.T) {
+	server := startCertificateServer(t, time.Now().Add(-24*time.Hour))
+	defer server.Close()
+
+	err := makeTestCertificateService(server, 0).Check(context.Background())
+	var certErr *CertificateError
+	if !errors.As(err, &certErr) || !strings.Contains(err.Error(), "has expired 1.0 days ago") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCertificateService_Check_NotTls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	service := MakeCertificateService(strings.TrimPrefix(server.URL, "http://"), nil, &net.Dialer{}, time.Second, time.Hour)
+	err := service.Check(context.Background())
+	var plaintextErr *PlaintextError
+	var certErr *CertificateError
+	if !errors.As(err, &plaintextErr) || errors.As(err, &certErr) || !strings.HasPrefix(err.Error(), "server does not speak TLS") {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestCertificateService_Report(t *testing.T) {
+	server := startCertificateServer(t, time.Now().Add(30*24*time.Hour))
+	defer server.Close()
+	proxy := MakeHopefulProxy(MakeLoggingServiceDecorator(makeTestCertificateService(server, 14), silentLogger()), 0, 1, silentLogger())
+	metrics := MakeMetricsHandler(&fragileStub{isOk: true})
+	metrics.Register(proxy, serviceIdentity{name: "certificate", kind: ServiceTypeCertificate, endpoint: "localhost:443"})
+	if proxy.Report().DaysToExpiry != nil {
+		t.Errorf("Unexpected days to expiry before the check")
+	}
+	_ = proxy.Check(context.Background())
+
+	report := proxy.Report()
+	if report.DaysToExpiry == nil || *report.DaysToExpiry < 29 {
+		t.Errorf("Unexpected days to expiry %v", report.DaysToExpiry)
+	}
+	details := MakeDetailsHandler("", []Reporter{proxy}).collect()
+	if details.Services[0].DaysToExpiry == nil {
+		t.Errorf("Days to expiry are missing in the details")
+	}
+	rr := httptest.NewRecorder()
+	metrics.ServeHTTP(rr, &http.Request{Method: http.MethodGet})
+	if !strings.Contains(rr.Body.String(), `healthcheck_service_certificate_days_to_expiry{service="certificate",type="certificate",endpoint="localhost:443"} `) {
+		t.Errorf("Days to expiry are missing in the metrics:\n%s", rr.Body.String())
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// TimeoutError marks check failures caused by an exhausted connect, handshake or total deadline
+type TimeoutError struct {
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out: %s", e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// CertificateError marks check failures caused by an untrusted, invalid or rejected certificate
+type CertificateError struct {
+	Err error
+}
+
+func (e *CertificateError) Error() string {
+	return fmt.Sprintf("certificate error: %s", e.Err)
+}
+
+func (e *CertificateError) Unwrap() error {
+	return e.Err
+}
+
+// PlaintextError marks check failures of a tls client, which the server answers in plain text, i.e. a scheme mismatch
+type PlaintextError struct {
+	Err error
+}
+
+func (e *PlaintextError) Error() string {
+	return fmt.Sprintf("server does not speak TLS: %s", e.Err)
+}
+
+func (e *PlaintextError) Unwrap() error {
+	return e.Err
+}
+
+func classifyError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return &TimeoutError{Err: err}
+	}
+	if isPlaintextError(err) {
+		return &PlaintextError{Err: err}
+	}
+	if isCertificateError(err) {
+		return &CertificateError{Err: err}
+	}
+	return err
+}
+
+// plaintextResponse is the text of the error, by which net/http replaces the tls.RecordHeaderError of an http response
+const plaintextResponse = "server gave HTTP response to HTTPS client"
+
+func isPlaintextError(err error) bool {
+	var recordHeader tls.RecordHeaderError
+	return errors.As(err, &recordHeader) || strings.Contains(err.Error(), plaintextResponse)
+}
+
+func isCertificateError(err error) bool {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	var systemRoots x509.SystemRootsError
+	var opErr *net.OpError
+	// the server rejects the client certificate with an alert
+	remoteAlert := errors.As(err, &opErr) && opErr.Op == "remote error"
+	return errors.As(err, &unknownAuthority) || errors.As(err, &invalid) || errors.As(err, &hostname) ||
+		errors.As(err, &systemRoots) || remoteAlert
+}
@@ -4,14 +4,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/sirupsen/logrus"
 )
 
 type ConfigLoggingLevel struct {
-	Root string `mapstrucutre:"root"`
+	Root      string `mapstructure:"root"`
+	Scheduler string `mapstructure:"scheduler"`
+	Server    string `mapstructure:"server"`
+	Checks    string `mapstructure:"checks"`
+	Notifier  string `mapstructure:"notifier"`
 }
 
+func (level ConfigLoggingLevel) components() map[string]string {
+	return map[string]string{
+		ComponentScheduler: level.Scheduler,
+		ComponentServer:    level.Server,
+		ComponentChecks:    level.Checks,
+		ComponentNotifier:  level.Notifier,
+	}
+}
+
+const (
+	LogFormatJava   = "java"
+	LogFormatJson   = "json"
+	LogFormatLogfmt = "logfmt"
+)
+
 type LoggingConfig struct {
-	Level ConfigLoggingLevel `mapstructure:"level"`
+	Level  ConfigLoggingLevel `mapstructure:"level"`
+	Format string             `mapstructure:"format"`
 }
 
 type ScheduleConfig struct {
@@ -19,23 +40,190 @@ type ScheduleConfig struct {
 	Delay   int  `mapstructure:"delay"`
 }
 
+const (
+	ServiceTypeHttp        = "http"
+	ServiceTypeTcp         = "tcp"
+	ServiceTypeCertificate = "certificate"
+)
+
+const (
+	SchemeHttp  = "http"
+	SchemeHttps = "https"
+)
+
+const (
+	AffectsReadiness = "readiness"
+	AffectsLiveness  = "liveness"
+	AffectsNone      = "none"
+)
+
+const (
+	GroupPolicyAll        = "all"
+	GroupPolicyAny        = "any"
+	GroupPolicyAtLeast    = "at-least"
+	GroupPolicyPercentage = "percentage"
+)
+
+const (
+	RuleStateUp      = "up"
+	RuleStateDown    = "down"
+	RuleEffectIgnore = "ignore"
+	RuleEffectFail   = "fail"
+	RuleTargetAll    = "*"
+	// RuleGeo refers to the geo-healthcheck in the rules
+	RuleGeo = "geo-healthcheck"
+)
+
+const (
+	CriticalityCritical    = "critical"
+	CriticalityNonCritical = "non-critical"
+)
+
+type ServiceScheduleConfig struct {
+	Interval     int    `mapstructure:"interval"`
+	InitialDelay int    `mapstructure:"initial-delay"`
+	Jitter       int    `mapstructure:"jitter"`
+	Cron         string `mapstructure:"cron"`
+}
+
+type TimeoutsConfig struct {
+	Connect      int `mapstructure:"connect"`
+	TlsHandshake int `mapstructure:"tls-handshake"`
+	Total        int `mapstructure:"total"`
+}
+
+type HttpClientConfig struct {
+	Timeouts            TimeoutsConfig `mapstructure:"timeouts"`
+	KeepAlive           int            `mapstructure:"keep-alive"`
+	MaxIdleConns        int            `mapstructure:"max-idle-conns"`
+	MaxIdleConnsPerHost int            `mapstructure:"max-idle-conns-per-host"`
+	IdleConnTimeout     int            `mapstructure:"idle-conn-timeout"`
+	DisableKeepAlives   bool           `mapstructure:"disable-keep-alives"`
+}
+
+type TlsConfig struct {
+	CaFile             string `mapstructure:"ca-file"`
+	CertFile           string `mapstructure:"cert-file"`
+	KeyFile            string `mapstructure:"key-file"`
+	ServerName         string `mapstructure:"server-name"`
+	InsecureSkipVerify bool   `mapstructure:"insecure-skip-verify"`
+}
+
+type BodyAssertionDescription struct {
+	JsonPath string `mapstructure:"json-path"`
+	Equals   string `mapstructure:"equals"`
+	Contains string `mapstructure:"contains"`
+	Regex    string `mapstructure:"regex"`
+}
+
 type ServiceDescription struct {
-	Name string `mapstructure:"service-name"`
-	Port int    `mapstructure:"port"`
-	Path string `mapstructure:"path"`
+	Type             string                     `mapstructure:"type"`
+	Name             string                     `mapstructure:"service-name"`
+	Port             int                        `mapstructure:"port"`
+	Path             string                     `mapstructure:"path"`
+	Scheme           string                     `mapstructure:"scheme"`
+	Tls              TlsConfig                  `mapstructure:"tls"`
+	Expect           string                     `mapstructure:"expect"`
+	ExpiryWindowDays int                        `mapstructure:"expiry-window-days"`
+	Method           string                     `mapstructure:"method"`
+	Headers          map[string]string          `mapstructure:"headers"`
+	Body             string                     `mapstructure:"body"`
+	AcceptedStatuses []string                   `mapstructure:"accepted-statuses"`
+	FollowRedirects  *bool                      `mapstructure:"follow-redirects"`
+	Assertions       []BodyAssertionDescription `mapstructure:"assertions"`
+	MaxBodySize      int64                      `mapstructure:"max-body-size"`
+	Timeouts         TimeoutsConfig             `mapstructure:"timeouts"`
+	FailureThreshold int                        `mapstructure:"failure-threshold"`
+	SuccessThreshold int                        `mapstructure:"success-threshold"`
+	Schedule         ServiceScheduleConfig      `mapstructure:"schedule"`
+	Affects          string                     `mapstructure:"affects"`
+	Criticality      string                     `mapstructure:"criticality"`
+	Group            string                     `mapstructure:"group"`
+}
+
+type GroupDescription struct {
+	Name        string  `mapstructure:"name"`
+	Policy      string  `mapstructure:"policy"`
+	Min         int     `mapstructure:"min"`
+	Percentage  float64 `mapstructure:"percentage"`
+	Criticality string  `mapstructure:"criticality"`
+}
+
+// RuleDescription reads as "when <service> is <state> then <effect> <targets>"
+type RuleDescription struct {
+	When    string   `mapstructure:"when"`
+	Is      string   `mapstructure:"is"`
+	Then    string   `mapstructure:"then"`
+	Targets []string `mapstructure:"targets"`
 }
 
 type ClientServicesConfig struct {
 	Services []ServiceDescription `mapstructure:"service-list"`
+	Groups   []GroupDescription   `mapstructure:"groups"`
+	Rules    []RuleDescription    `mapstructure:"rules"`
 }
 
 type GeoConfig struct {
-	Service string `mapstructure:"service-name"`
-	Port    int    `mapstructure:"port"`
+	Service  string                `mapstructure:"service-name"`
+	Port     int                   `mapstructure:"port"`
+	Timeouts TimeoutsConfig        `mapstructure:"timeouts"`
+	Schedule ServiceScheduleConfig `mapstructure:"schedule"`
+}
+
+// Secret is masked when the configuration is printed
+type Secret string
+
+func (secret Secret) MarshalJSON() ([]byte, error) {
+	if secret == "" {
+		return json.Marshal("")
+	}
+	return json.Marshal("******")
+}
+
+type ServerTlsConfig struct {
+	CertFile string `mapstructure:"cert-file"`
+	KeyFile  string `mapstructure:"key-file"`
+}
+
+// ServerAuthConfig guards the endpoints except for the probes, a request passes with either of the credentials
+type ServerAuthConfig struct {
+	Token    Secret `mapstructure:"token"`
+	Username string `mapstructure:"username"`
+	Password Secret `mapstructure:"password"`
 }
 
 type ServerConfig struct {
-	Port int `mapstructure:"port"`
+	Port int              `mapstructure:"port"`
+	Tls  ServerTlsConfig  `mapstructure:"tls"`
+	Auth ServerAuthConfig `mapstructure:"auth"`
+}
+
+type ShutdownConfig struct {
+	GracePeriod int `mapstructure:"grace-period"`
+}
+
+type WebhookConfig struct {
+	Url      string            `mapstructure:"url"`
+	Template string            `mapstructure:"template"`
+	Headers  map[string]string `mapstructure:"headers"`
+}
+
+type NotificationsConfig struct {
+	Webhooks    []WebhookConfig `mapstructure:"webhooks"`
+	Retries     int             `mapstructure:"retries"`
+	Backoff     int             `mapstructure:"backoff"`
+	DedupWindow int             `mapstructure:"dedup-window"`
+	Timeouts    TimeoutsConfig  `mapstructure:"timeouts"`
+}
+
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"address"`
+	Token   Secret `mapstructure:"token"`
+}
+
+type HistoryConfig struct {
+	Size int `mapstructure:"size"`
 }
 
 type PodConfig struct {
@@ -50,6 +238,12 @@ type Config struct {
 	ClientServices   ClientServicesConfig `mapstructure:"client-services"`
 	Geo              *GeoConfig           `mapstructure:"geo-healthcheck"`
 	FailureThreshold int                  `mapstructure:"failure-threshold"`
+	SuccessThreshold int                  `mapstructure:"success-threshold"`
+	HttpClient       HttpClientConfig     `mapstructure:"http-client"`
+	Shutdown         ShutdownConfig       `mapstructure:"shutdown"`
+	History          HistoryConfig        `mapstructure:"history"`
+	Notifications    NotificationsConfig  `mapstructure:"notifications"`
+	Admin            AdminConfig          `mapstructure:"admin"`
 }
 
 func (config *Config) AsJson() string {
@@ -61,17 +255,225 @@ func (config *Config) AsJson() string {
 }
 
 func (config *Config) Verify() error {
+	if config.Shutdown.GracePeriod < 0 {
+		return fmt.Errorf("only non-negative values are valid for config.shutdown.grace-period: %d", config.Shutdown.GracePeriod)
+	}
+	if _, err := MakeLogFormatter(config.Logging.Format); err != nil {
+		return err
+	}
+	for component, level := range config.Logging.Level.components() {
+		if _, err := logrus.ParseLevel(level); level != "" && err != nil {
+			return fmt.Errorf("invalid log level of %s: %w", component, err)
+		}
+	}
+	if config.Admin.Enabled && config.Admin.Address == "" && config.Admin.Token == "" {
+		return errors.New("admin endpoint must be guarded by admin.address or admin.token")
+	}
+	if (config.Server.Tls.CertFile == "") != (config.Server.Tls.KeyFile == "") {
+		return errors.New("both server.tls.cert-file and server.tls.key-file are required for https")
+	}
+	if (config.Server.Auth.Username == "") != (config.Server.Auth.Password == "") {
+		return errors.New("both server.auth.username and server.auth.password are required for basic authentication")
+	}
+	if config.History.Size < 0 {
+		return fmt.Errorf("only non-negative values are valid for config.history.size: %d", config.History.Size)
+	}
+	if err := config.Notifications.verify(); err != nil {
+		return err
+	}
 	if !config.Schedule.Enabled {
 		return nil
 	}
 	if config.Geo == nil && config.ClientServices.Services == nil {
 		return errors.New("scheduling is enabled, but no services to check are provided")
 	}
+	groups := map[string]bool{}
+	for _, group := range config.ClientServices.Groups {
+		if err := group.verify(); err != nil {
+			return err
+		}
+		if groups[group.Name] {
+			return fmt.Errorf("duplicate group '%s'", group.Name)
+		}
+		groups[group.Name] = true
+	}
+	for _, service := range config.ClientServices.Services {
+		if service.Group != "" && !groups[service.Group] {
+			return fmt.Errorf("unknown group '%s' of service '%s'", service.Group, service.Name)
+		}
+		switch service.Type {
+		case "", ServiceTypeHttp, ServiceTypeTcp, ServiceTypeCertificate:
+		default:
+			return fmt.Errorf("unknown type '%s' of service '%s'", service.Type, service.Name)
+		}
+		switch service.Scheme {
+		case "", SchemeHttp, SchemeHttps:
+		default:
+			return fmt.Errorf("unknown scheme '%s' of service '%s'", service.Scheme, service.Name)
+		}
+		if _, err := MakeTlsConfig(service.Tls); err != nil {
+			return fmt.Errorf("invalid tls of service '%s': %w", service.Name, err)
+		}
+		switch service.Affects {
+		case "", AffectsReadiness, AffectsLiveness, AffectsNone:
+		default:
+			return fmt.Errorf("unknown probe '%s' affected by service '%s'", service.Affects, service.Name)
+		}
+		switch service.Criticality {
+		case "", CriticalityCritical, CriticalityNonCritical:
+		default:
+			return fmt.Errorf("unknown criticality '%s' of service '%s'", service.Criticality, service.Name)
+		}
+		if _, err := ParseStatusRanges(service.AcceptedStatuses); err != nil {
+			return fmt.Errorf("invalid accepted-statuses of service '%s': %w", service.Name, err)
+		}
+		if err := service.Timeouts.verify(fmt.Sprintf("timeouts of service '%s'", service.Name)); err != nil {
+			return err
+		}
+		if err := service.Schedule.verify(fmt.Sprintf("schedule of service '%s'", service.Name)); err != nil {
+			return err
+		}
+		if service.ExpiryWindowDays < 0 {
+			return fmt.Errorf("only non-negative values are valid for expiry-window-days of service '%s': %d", service.Name, service.ExpiryWindowDays)
+		}
+		if service.FailureThreshold < 0 || service.SuccessThreshold < 0 {
+			return fmt.Errorf("only non-negative thresholds are valid for service '%s'", service.Name)
+		}
+		for _, assertion := range service.Assertions {
+			if _, err := MakeBodyAssertion(assertion); err != nil {
+				return fmt.Errorf("invalid assertion of service '%s': %w", service.Name, err)
+			}
+		}
+	}
+	if err := config.verifyRules(); err != nil {
+		return err
+	}
+	if err := config.HttpClient.Timeouts.verify("http-client.timeouts"); err != nil {
+		return err
+	}
+	if config.Geo != nil {
+		if err := config.Geo.Timeouts.verify("geo-healthcheck.timeouts"); err != nil {
+			return err
+		}
+		if err := config.Geo.Schedule.verify("geo-healthcheck.schedule"); err != nil {
+			return err
+		}
+	}
 	if config.FailureThreshold <= 0 {
 		return fmt.Errorf("only positive values are valid for config.failure-threshold: %d", config.FailureThreshold)
 	}
+	if config.SuccessThreshold < 0 {
+		return fmt.Errorf("only non-negative values are valid for config.success-threshold: %d", config.SuccessThreshold)
+	}
 	if config.Schedule.Enabled && config.Schedule.Delay <= 0 {
 		return fmt.Errorf("only positive values are valid for config.schedule.delay: %d", config.FailureThreshold)
 	}
 	return nil
 }
+
+func (config *Config) verifyRules() error {
+	// conditions may refer to any service, targets only to the services, which are not grouped
+	conditions := map[string]int{}
+	targets := map[string]bool{}
+	for _, group := range config.ClientServices.Groups {
+		conditions[group.Name]++
+		targets[group.Name] = true
+	}
+	for _, service := range config.ClientServices.Services {
+		conditions[service.Name]++
+		if service.Group == "" {
+			targets[service.Name] = true
+		}
+	}
+	if config.Geo != nil {
+		conditions[RuleGeo]++
+		targets[RuleGeo] = true
+	}
+	for _, rule := range config.ClientServices.Rules {
+		switch conditions[rule.When] {
+		case 0:
+			return fmt.Errorf("unknown service '%s' in the condition of a rule", rule.When)
+		case 1:
+		default:
+			return fmt.Errorf("ambiguous service '%s' in the condition of a rule", rule.When)
+		}
+		if rule.Is != RuleStateUp && rule.Is != RuleStateDown {
+			return fmt.Errorf("unknown state '%s' in the rule for '%s'", rule.Is, rule.When)
+		}
+		if rule.Then != RuleEffectIgnore && rule.Then != RuleEffectFail {
+			return fmt.Errorf("unknown effect '%s' in the rule for '%s'", rule.Then, rule.When)
+		}
+		if len(rule.Targets) == 0 {
+			return fmt.Errorf("no targets in the rule for '%s'", rule.When)
+		}
+		for _, target := range rule.Targets {
+			if target != RuleTargetAll && !targets[target] {
+				return fmt.Errorf("unknown or grouped target '%s' in the rule for '%s'", target, rule.When)
+			}
+		}
+	}
+	return nil
+}
+
+func (notifications NotificationsConfig) verify() error {
+	if notifications.Retries < 0 || notifications.Backoff < 0 || notifications.DedupWindow < 0 {
+		return fmt.Errorf("only non-negative values are valid for notifications: retries=%d, backoff=%d, dedup-window=%d",
+			notifications.Retries, notifications.Backoff, notifications.DedupWindow)
+	}
+	if err := notifications.Timeouts.verify("notifications.timeouts"); err != nil {
+		return err
+	}
+	for _, webhook := range notifications.Webhooks {
+		if webhook.Url == "" {
+			return errors.New("webhooks must have an url")
+		}
+		if _, err := makeWebhookTemplate(webhook.Template); err != nil {
+			return fmt.Errorf("invalid template of webhook '%s': %w", webhook.Url, err)
+		}
+	}
+	return nil
+}
+
+func (group GroupDescription) verify() error {
+	if group.Name == "" {
+		return errors.New("groups must have a name")
+	}
+	switch group.Policy {
+	case "", GroupPolicyAll, GroupPolicyAny:
+	case GroupPolicyAtLeast:
+		if group.Min <= 0 {
+			return fmt.Errorf("only positive values are valid for min of group '%s': %d", group.Name, group.Min)
+		}
+	case GroupPolicyPercentage:
+		if group.Percentage <= 0 || group.Percentage > 100 {
+			return fmt.Errorf("only values in (0, 100] are valid for percentage of group '%s': %g", group.Name, group.Percentage)
+		}
+	default:
+		return fmt.Errorf("unknown policy '%s' of group '%s'", group.Policy, group.Name)
+	}
+	switch group.Criticality {
+	case "", CriticalityCritical, CriticalityNonCritical:
+	default:
+		return fmt.Errorf("unknown criticality '%s' of group '%s'", group.Criticality, group.Name)
+	}
+	return nil
+}
+
+func (timeouts TimeoutsConfig) verify(name string) error {
+	if timeouts.Connect < 0 || timeouts.TlsHandshake < 0 || timeouts.Total < 0 {
+		return fmt.Errorf("only non-negative values are valid for %s: %+v", name, timeouts)
+	}
+	return nil
+}
+
+func (schedule ServiceScheduleConfig) verify(name string) error {
+	if schedule.Interval < 0 || schedule.InitialDelay < 0 || schedule.Jitter < 0 {
+		return fmt.Errorf("only non-negative values are valid for %s: %+v", name, schedule)
+	}
+	if schedule.Cron != "" {
+		if err := VerifyCronExpression(schedule.Cron); err != nil {
+			return fmt.Errorf("invalid cron expression of %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -11,25 +12,333 @@ func TestConfig_AsJson(t *testing.T) {
     "Namespace": ""
   },
   "Server": {
-    "Port": 0
+    "Port": 0,
+    "Tls": {
+      "CertFile": "",
+      "KeyFile": ""
+    },
+    "Auth": {
+      "Token": "",
+      "Username": "",
+      "Password": ""
+    }
   },
   "Logging": {
     "Level": {
-      "Root": ""
-    }
+      "Root": "",
+      "Scheduler": "",
+      "Server": "",
+      "Checks": "",
+      "Notifier": ""
+    },
+    "Format": ""
   },
   "Schedule": {
     "Enabled": false,
     "Delay": 0
   },
   "ClientServices": {
-    "Services": null
+    "Services": null,
+    "Groups": null,
+    "Rules": null
   },
   "Geo": null,
-  "FailureThreshold": 0
+  "FailureThreshold": 0,
+  "SuccessThreshold": 0,
+  "HttpClient": {
+    "Timeouts": {
+      "Connect": 0,
+      "TlsHandshake": 0,
+      "Total": 0
+    },
+    "KeepAlive": 0,
+    "MaxIdleConns": 0,
+    "MaxIdleConnsPerHost": 0,
+    "IdleConnTimeout": 0,
+    "DisableKeepAlives": false
+  },
+  "Shutdown": {
+    "GracePeriod": 0
+  },
+  "History": {
+    "Size": 0
+  },
+  "Notifications": {
+    "Webhooks": null,
+    "Retries": 0,
+    "Backoff": 0,
+    "DedupWindow": 0,
+    "Timeouts": {
+      "Connect": 0,
+      "TlsHandshake": 0,
+      "Total": 0
+    }
+  },
+  "Admin": {
+    "Enabled": false,
+    "Address": "",
+    "Token": ""
+  }
 }`
 	jsonString := config.AsJson()
 	if jsonString != expected {
 		t.Errorf("Unexpected json: %s", jsonString)
 	}
 }
+
+func TestConfig_Verify_UnknownServiceType(t *testing.T) {
+	config := Config{
+		Schedule:         ScheduleConfig{Enabled: true, Delay: 1000},
+		FailureThreshold: 3,
+		ClientServices: ClientServicesConfig{
+			Services: []ServiceDescription{{Type: "udp", Name: "name", Port: 53}},
+		},
+	}
+	err := config.Verify()
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if err.Error() != "unknown type 'udp' of service 'name'" {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestConfig_Verify_InvalidCron(t *testing.T) {
+	config := Config{
+		Schedule:         ScheduleConfig{Enabled: true, Delay: 1000},
+		FailureThreshold: 3,
+		ClientServices: ClientServicesConfig{
+			Services: []ServiceDescription{{Name: "name", Port: 80, Schedule: ServiceScheduleConfig{Cron: "every day"}}},
+		},
+	}
+	if err := config.Verify(); err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+}
+
+func TestConfig_Verify_UnknownProbe(t *testing.T) {
+	config := Config{
+		Schedule:         ScheduleConfig{Enabled: true, Delay: 1000},
+		FailureThreshold: 3,
+		ClientServices: ClientServicesConfig{
+			Services: []ServiceDescription{{Name: "name", Port: 80, Affects: "startup"}},
+		},
+	}
+	err := config.Verify()
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if err.Error() != "unknown probe 'startup' affected by service 'name'" {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestConfig_Verify_NegativeHistorySize(t *testing.T) {
+	config := Config{History: HistoryConfig{Size: -1}}
+	err := config.Verify()
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if err.Error() != "only non-negative values are valid for config.history.size: -1" {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestConfig_Verify_UnknownCriticality(t *testing.T) {
+	config := Config{
+		Schedule:         ScheduleConfig{Enabled: true, Delay: 1000},
+		FailureThreshold: 3,
+		ClientServices: ClientServicesConfig{
+			Services: []ServiceDescription{{Name: "name", Port: 80, Criticality: "minor"}},
+		},
+	}
+	err := config.Verify()
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if err.Error() != "unknown criticality 'minor' of service 'name'" {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestConfig_Verify_Groups(t *testing.T) {
+	for _, testCase := range []struct {
+		groups   []GroupDescription
+		group    string
+		expected string
+	}{
+		{nil, "cache", "unknown group 'cache' of service 'name'"},
+		{[]GroupDescription{{Name: "cache"}, {Name: "cache"}}, "cache", "duplicate group 'cache'"},
+		{[]GroupDescription{{Name: "cache", Policy: "most"}}, "cache", "unknown policy 'most' of group 'cache'"},
+		{[]GroupDescription{{Name: "cache", Policy: GroupPolicyAtLeast}}, "cache", "only positive values are valid for min of group 'cache': 0"},
+		{[]GroupDescription{{Name: "cache", Policy: GroupPolicyPercentage, Percentage: 150}}, "cache", "only values in (0, 100] are valid for percentage of group 'cache': 150"},
+		{[]GroupDescription{{Name: "cache", Criticality: "minor"}}, "cache", "unknown criticality 'minor' of group 'cache'"},
+		{[]GroupDescription{{}}, "", "groups must have a name"},
+	} {
+		config := Config{
+			Schedule:         ScheduleConfig{Enabled: true, Delay: 1000},
+			FailureThreshold: 3,
+			ClientServices: ClientServicesConfig{
+				Services: []ServiceDescription{{Name: "name", Port: 80, Group: testCase.group}},
+				Groups:   testCase.groups,
+			},
+		}
+		err := config.Verify()
+		if err == nil {
+			t.Fatalf("Unexpected nil error")
+		}
+		if err.Error() != testCase.expected {
+			t.Errorf("Unexpected error: %s, expected=%s", err.Error(), testCase.expected)
+		}
+	}
+}
+
+func TestConfig_Verify_Rules(t *testing.T) {
+	for _, testCase := range []struct {
+		rule     RuleDescription
+		expected string
+	}{
+		{RuleDescription{When: "unknown", Is: RuleStateUp, Then: RuleEffectIgnore, Targets: []string{"*"}}, "unknown service 'unknown' in the condition of a rule"},
+		{RuleDescription{When: "twin", Is: RuleStateUp, Then: RuleEffectIgnore, Targets: []string{"*"}}, "ambiguous service 'twin' in the condition of a rule"},
+		{RuleDescription{When: "name", Is: "flapping", Then: RuleEffectIgnore, Targets: []string{"*"}}, "unknown state 'flapping' in the rule for 'name'"},
+		{RuleDescription{When: "name", Is: RuleStateUp, Then: "mask", Targets: []string{"*"}}, "unknown effect 'mask' in the rule for 'name'"},
+		{RuleDescription{When: "name", Is: RuleStateUp, Then: RuleEffectIgnore}, "no targets in the rule for 'name'"},
+		{RuleDescription{When: "name", Is: RuleStateUp, Then: RuleEffectIgnore, Targets: []string{"member"}}, "unknown or grouped target 'member' in the rule for 'name'"},
+	} {
+		config := Config{
+			Schedule:         ScheduleConfig{Enabled: true, Delay: 1000},
+			FailureThreshold: 3,
+			ClientServices: ClientServicesConfig{
+				Services: []ServiceDescription{
+					{Name: "name", Port: 80},
+					{Name: "twin", Port: 80},
+					{Name: "twin", Port: 81},
+					{Name: "member", Port: 80, Group: "group"},
+				},
+				Groups: []GroupDescription{{Name: "group"}},
+				Rules:  []RuleDescription{testCase.rule},
+			},
+		}
+		err := config.Verify()
+		if err == nil {
+			t.Fatalf("Unexpected nil error")
+		}
+		if err.Error() != testCase.expected {
+			t.Errorf("Unexpected error: %s, expected=%s", err.Error(), testCase.expected)
+		}
+	}
+}
+
+func TestConfig_Verify_Notifications(t *testing.T) {
+	for _, testCase := range []struct {
+		notifications NotificationsConfig
+		expected      string
+	}{
+		{NotificationsConfig{Retries: -1}, "only non-negative values are valid for notifications: retries=-1, backoff=0, dedup-window=0"},
+		{NotificationsConfig{Webhooks: []WebhookConfig{{}}}, "webhooks must have an url"},
+		{NotificationsConfig{Webhooks: []WebhookConfig{{Url: "http://hook", Template: "{{.Service"}}}, "invalid template of webhook 'http://hook': template: webhook:1: unclosed action"},
+	} {
+		config := Config{Notifications: testCase.notifications}
+		err := config.Verify()
+		if err == nil {
+			t.Fatalf("Unexpected nil error")
+		}
+		if err.Error() != testCase.expected {
+			t.Errorf("Unexpected error: %s, expected=%s", err.Error(), testCase.expected)
+		}
+	}
+}
+
+func TestConfig_Verify_UnknownLogFormat(t *testing.T) {
+	config := Config{Logging: LoggingConfig{Format: "xml"}}
+	err := config.Verify()
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if err.Error() != "unknown logging format 'xml'" {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestConfig_Verify_InvalidComponentLogLevel(t *testing.T) {
+	config := Config{Logging: LoggingConfig{Level: ConfigLoggingLevel{Scheduler: "loud"}}}
+	err := config.Verify()
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if err.Error() != "invalid log level of scheduler: not a valid logrus Level: \"loud\"" {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestConfig_Verify_UnguardedAdmin(t *testing.T) {
+	config := Config{Admin: AdminConfig{Enabled: true}}
+	err := config.Verify()
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if err.Error() != "admin endpoint must be guarded by admin.address or admin.token" {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestConfig_Verify_Tls(t *testing.T) {
+	for _, testCase := range []struct {
+		service  ServiceDescription
+		expected string
+	}{
+		{ServiceDescription{Name: "name", Port: 80, Scheme: "ftp"}, "unknown scheme 'ftp' of service 'name'"},
+		{ServiceDescription{Name: "name", Port: 80, Scheme: SchemeHttps, Tls: TlsConfig{KeyFile: "client.key"}},
+			"invalid tls of service 'name': both cert-file and key-file are required for a client certificate"},
+		{ServiceDescription{Name: "name", Type: ServiceTypeCertificate, Port: 443, ExpiryWindowDays: -1},
+			"only non-negative values are valid for expiry-window-days of service 'name': -1"},
+	} {
+		config := Config{
+			Schedule:         ScheduleConfig{Enabled: true, Delay: 1000},
+			FailureThreshold: 3,
+			ClientServices:   ClientServicesConfig{Services: []ServiceDescription{testCase.service}},
+		}
+		err := config.Verify()
+		if err == nil {
+			t.Fatalf("Unexpected nil error")
+		}
+		if err.Error() != testCase.expected {
+			t.Errorf("Unexpected error: %s, expected=%s", err.Error(), testCase.expected)
+		}
+	}
+}
+
+func TestConfig_AsJson_MasksSecrets(t *testing.T) {
+	config := Config{
+		Server: ServerConfig{Auth: ServerAuthConfig{Token: "token", Username: "user", Password: "password"}},
+		Admin:  AdminConfig{Token: "admin-token"},
+	}
+	jsonString := config.AsJson()
+	for _, secret := range []string{"\"token\"", "\"password\"", "admin-token"} {
+		if strings.Contains(jsonString, secret) {
+			t.Errorf("Secret %s is not masked: %s", secret, jsonString)
+		}
+	}
+	if !strings.Contains(jsonString, `"Username": "user"`) {
+		t.Errorf("Unexpected json: %s", jsonString)
+	}
+}
+
+func TestConfig_Verify_Server(t *testing.T) {
+	for _, testCase := range []struct {
+		server   ServerConfig
+		expected string
+	}{
+		{ServerConfig{Tls: ServerTlsConfig{CertFile: "server.crt"}}, "both server.tls.cert-file and server.tls.key-file are required for https"},
+		{ServerConfig{Auth: ServerAuthConfig{Username: "user"}}, "both server.auth.username and server.auth.password are required for basic authentication"},
+	} {
+		config := Config{Server: testCase.server}
+		err := config.Verify()
+		if err == nil {
+			t.Fatalf("Unexpected nil error")
+		}
+		if err.Error() != testCase.expected {
+			t.Errorf("Unexpected error: %s, expected=%s", err.Error(), testCase.expected)
+		}
+	}
+}
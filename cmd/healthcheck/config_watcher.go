@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"path/filepath"
+)
+
+// watchConfigFile calls onChange when the file is written or replaced, e.g. when a mounted config map swaps its symlink,
+// unlike viper.WatchConfig it does not read the file, so that onChange is the only reader
+func watchConfigFile(file string, onChange func(), logger logrus.FieldLogger) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("can not watch configuration file: %w", err)
+	}
+	configFile := filepath.Clean(file)
+	configDir, _ := filepath.Split(configFile)
+	realConfigFile, _ := filepath.EvalSymlinks(configFile)
+	if err := watcher.Add(configDir); err != nil {
+		_ = watcher.Close()
+		return fmt.Errorf("can not watch configuration directory '%s': %w", configDir, err)
+	}
+	go func() {
+		defer func() { _ = watcher.Close() }()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				currentConfigFile, _ := filepath.EvalSymlinks(configFile)
+				written := filepath.Clean(event.Name) == configFile && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+				replaced := currentConfigFile != "" && currentConfigFile != realConfigFile
+				if written || replaced {
+					realConfigFile = currentConfigFile
+					logger.Infof("configuration file '%s' has changed", event.Name)
+					onChange()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warnf("can not watch configuration file: %s", err)
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func awaitChange(t *testing.T, changes chan bool, message string) {
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatalf(message)
+	}
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("schedule:\n  enabled: false\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	changes := make(chan bool, 10)
+	if err := watchConfigFile(file, func() { changes <- true }, silentLogger()); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if err := ioutil.WriteFile(file, []byte("schedule:\n  enabled: true\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	awaitChange(t, changes, "Change should be reported after the file is written")
+
+	// a write may produce several events
+	time.Sleep(100 * time.Millisecond)
+	for len(changes) > 0 {
+		<-changes
+	}
+
+	replacement := filepath.Join(dir, "replacement.yaml")
+	if err := ioutil.WriteFile(replacement, []byte("schedule:\n  enabled: false\n"), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if err := os.Rename(replacement, file); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	awaitChange(t, changes, "Change should be reported after the file is replaced")
+}
+
+func TestWatchConfigFile_MissingDirectory(t *testing.T) {
+	if err := watchConfigFile("/missing/config.yaml", func() {}, silentLogger()); err == nil {
+		t.Errorf("Unexpected nil error")
+	}
+}
@@ -1,30 +1,163 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	cron "github.com/go-co-op/gocron"
 	log "github.com/sirupsen/logrus"
+	"math/rand"
+	"strings"
+	"sync"
 	"time"
 )
 
+// Schedule defines when a service is checked, Cron takes precedence over Interval,
+// a check is cancelled after Timeout unless it is zero
+type Schedule struct {
+	Interval     time.Duration
+	InitialDelay time.Duration
+	Jitter       time.Duration
+	Cron         string
+	Timeout      time.Duration
+}
+
+type ScheduledCheck struct {
+	Service  Service
+	Schedule Schedule
+}
+
+type scheduledJob struct {
+	job      *cron.Job
+	schedule Schedule
+}
+
 type CronScheduler struct {
 	scheduler *cron.Scheduler
 	logger    log.FieldLogger
+	mutex     sync.Mutex
+	jobs      map[Service]scheduledJob
+	stopped   chan struct{}
+	ctx       context.Context
+	cancel    context.CancelFunc
+}
+
+func MakeScheduler(checks []ScheduledCheck, logger log.FieldLogger) (*CronScheduler, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler := &CronScheduler{
+		scheduler: cron.NewScheduler(time.UTC),
+		logger:    logger,
+		jobs:      map[Service]scheduledJob{},
+		stopped:   make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	for _, check := range checks {
+		if err := scheduler.schedule(check); err != nil {
+			return nil, err
+		}
+	}
+	return scheduler, nil
+}
+
+func applySchedule(scheduler *cron.Scheduler, schedule Schedule) *cron.Scheduler {
+	if schedule.Cron != "" {
+		if len(strings.Fields(schedule.Cron)) == 6 {
+			scheduler = scheduler.CronWithSeconds(schedule.Cron)
+		} else {
+			scheduler = scheduler.Cron(schedule.Cron)
+		}
+	} else {
+		scheduler = scheduler.Every(int(schedule.Interval / time.Millisecond)).Milliseconds()
+	}
+	if schedule.InitialDelay > 0 {
+		scheduler = scheduler.StartAt(time.Now().Add(schedule.InitialDelay))
+	}
+	return scheduler
+}
+
+// VerifyCronExpression checks the expression with five or six (including seconds) fields
+func VerifyCronExpression(expression string) error {
+	_, err := applySchedule(cron.NewScheduler(time.UTC), Schedule{Cron: expression}).Do(func() {})
+	return err
+}
+
+func (ss *CronScheduler) schedule(check ScheduledCheck) error {
+	job, err := ss.makeJob(check)
+	if err != nil {
+		return err
+	}
+	ss.logger.Infof("polling the status of %s", check.Service.Print())
+	ss.jobs[check.Service] = job
+	return nil
 }
 
-func MakeScheduler(delay time.Duration, services []Service, logger log.FieldLogger) (Lifecycle, error) {
-	scheduler := cron.NewScheduler(time.UTC)
-	for _, service := range services {
-		logger.Infof("polling the status of %s", service.Print())
-		_, err := scheduler.Every(int(delay / time.Millisecond)).Milliseconds().Do(service.Check)
+func (ss *CronScheduler) makeJob(check ScheduledCheck) (scheduledJob, error) {
+	task := func() error {
+		if jitter := check.Schedule.Jitter; jitter > 0 {
+			select {
+			case <-time.After(time.Duration(rand.Int63n(int64(jitter)))):
+			case <-ss.ctx.Done():
+				return ss.ctx.Err()
+			}
+		}
+		ctx := ss.ctx
+		if check.Schedule.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, check.Schedule.Timeout)
+			defer cancel()
+		}
+		return check.Service.Check(ctx)
+	}
+	job, err := applySchedule(ss.scheduler, check.Schedule).Do(task)
+	if err != nil {
+		return scheduledJob{}, fmt.Errorf("can not status polling job: %w", err)
+	}
+	return scheduledJob{job: job, schedule: check.Schedule}, nil
+}
+
+func (ss *CronScheduler) unschedule(service Service) {
+	ss.logger.Infof("stopped polling the status of %s", service.Print())
+	ss.scheduler.RemoveByReference(ss.jobs[service].job)
+	delete(ss.jobs, service)
+}
+
+// Update replaces the set of polled services, keeping the jobs of services, which schedule has not changed,
+// the new jobs are made before the old ones are removed, so a failure leaves the previous jobs running
+func (ss *CronScheduler) Update(checks []ScheduledCheck) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	retained := map[Service]bool{}
+	for _, check := range checks {
+		if scheduled, ok := ss.jobs[check.Service]; ok && scheduled.schedule == check.Schedule {
+			retained[check.Service] = true
+		}
+	}
+	added := map[Service]scheduledJob{}
+	for _, check := range checks {
+		if retained[check.Service] {
+			continue
+		}
+		job, err := ss.makeJob(check)
 		if err != nil {
-			return nil, fmt.Errorf("can not status polling job: %w", err)
+			for _, scheduled := range added {
+				ss.scheduler.RemoveByReference(scheduled.job)
+			}
+			return err
 		}
+		added[check.Service] = job
 	}
-	return &CronScheduler{
-		scheduler: scheduler,
-		logger:    logger,
-	}, nil
+	for service := range ss.jobs {
+		if !retained[service] {
+			ss.unschedule(service)
+		}
+	}
+	for _, check := range checks {
+		if job, ok := added[check.Service]; ok {
+			ss.logger.Infof("polling the status of %s", check.Service.Print())
+			ss.jobs[check.Service] = job
+		}
+	}
+	return nil
 }
 
 func (ss *CronScheduler) StartAsync() {
@@ -32,13 +165,26 @@ func (ss *CronScheduler) StartAsync() {
 	ss.scheduler.StartAsync()
 }
 
-func (ss *CronScheduler) Shutdown() error {
+// Shutdown stops scheduling new checks, checks in flight are awaited in background
+func (ss *CronScheduler) Shutdown(context.Context) error {
 	ss.logger.Info("stopping scheduler")
-	ss.scheduler.Stop()
+	go func() {
+		ss.scheduler.Stop()
+		close(ss.stopped)
+	}()
 	return nil
 }
 
-func (ss *CronScheduler) AwaitShutdown() error {
-	ss.logger.Info("scheduler stopped")
-	return nil
+// AwaitShutdown cancels the checks in flight if they have not finished before ctx is done
+func (ss *CronScheduler) AwaitShutdown(ctx context.Context) error {
+	ss.logger.Info("waiting for checks in flight")
+	select {
+	case <-ss.stopped:
+		ss.cancel()
+		ss.logger.Info("scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		ss.cancel()
+		return fmt.Errorf("checks in flight have not finished in time and were cancelled: %w", ctx.Err())
+	}
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"github.com/sirupsen/logrus"
 	"testing"
 	"time"
@@ -12,7 +14,7 @@ type countingServiceMock struct {
 	duration     time.Duration
 }
 
-func (c *countingServiceMock) Check() error {
+func (c *countingServiceMock) Check(context.Context) error {
 	c.checkCounter++
 	return nil
 }
@@ -25,17 +27,17 @@ func TestCronScheduler_Lifecycle(t *testing.T) {
 	service := countingServiceMock{duration: 0}
 	logger := logrus.New()
 	logger.SetOutput(bytes.NewBufferString(""))
-	scheduler, err := MakeScheduler(300*time.Millisecond, []Service{&service}, logger)
+	scheduler, err := MakeScheduler([]ScheduledCheck{{Service: &service, Schedule: Schedule{Interval: 300 * time.Millisecond}}}, logger)
 	if err != nil {
 		t.Fatalf("Unexpected error: '%s'", err.Error())
 	}
 	scheduler.StartAsync()
 	time.Sleep(500 * time.Millisecond)
-	err = scheduler.Shutdown()
+	err = scheduler.Shutdown(context.Background())
 	if err != nil {
 		t.Fatalf("Unexpected error during shutdown: '%s'", err.Error())
 	}
-	err = scheduler.AwaitShutdown()
+	err = scheduler.AwaitShutdown(context.Background())
 	if err != nil {
 		t.Fatalf("Unexpected error during shutdown awaiting: '%s'", err.Error())
 	}
@@ -48,17 +50,17 @@ func TestCronScheduler_OverSchedule(t *testing.T) {
 	service := countingServiceMock{duration: 0}
 	logger := logrus.New()
 	logger.SetOutput(bytes.NewBufferString(""))
-	scheduler, err := MakeScheduler(time.Second, []Service{&service}, logger)
+	scheduler, err := MakeScheduler([]ScheduledCheck{{Service: &service, Schedule: Schedule{Interval: time.Second}}}, logger)
 	if err != nil {
 		t.Fatalf("Unexpected error: '%s'", err.Error())
 	}
 	scheduler.StartAsync()
 	time.Sleep(500 * time.Millisecond)
-	err = scheduler.Shutdown()
+	err = scheduler.Shutdown(context.Background())
 	if err != nil {
 		t.Fatalf("Unexpected error during shutdown: '%s'", err.Error())
 	}
-	err = scheduler.AwaitShutdown()
+	err = scheduler.AwaitShutdown(context.Background())
 	if err != nil {
 		t.Fatalf("Unexpected error during shutdown awaiting: '%s'", err.Error())
 	}
@@ -66,3 +68,220 @@ func TestCronScheduler_OverSchedule(t *testing.T) {
 		t.Fatalf("Unexpected service invocation number %d", service.checkCounter)
 	}
 }
+
+func TestCronScheduler_Update(t *testing.T) {
+	first := &countingServiceMock{}
+	second := &countingServiceMock{}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, err := MakeScheduler([]ScheduledCheck{{Service: first, Schedule: Schedule{Interval: time.Second}}}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	firstJob := scheduler.jobs[first].job
+	err = scheduler.Update([]ScheduledCheck{
+		{Service: first, Schedule: Schedule{Interval: time.Second}},
+		{Service: second, Schedule: Schedule{Interval: time.Second}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	if scheduler.jobs[first].job != firstJob || len(scheduler.jobs) != 2 {
+		t.Errorf("Unexpected jobs after adding a service")
+	}
+	err = scheduler.Update([]ScheduledCheck{{Service: second, Schedule: Schedule{Interval: 2 * time.Second}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	if _, ok := scheduler.jobs[first]; ok || len(scheduler.jobs) != 1 || len(scheduler.scheduler.Jobs()) != 1 {
+		t.Errorf("Unexpected jobs after removing a service")
+	}
+	if scheduler.jobs[second].schedule.Interval != 2*time.Second {
+		t.Errorf("Service should be rescheduled when its schedule changes")
+	}
+}
+
+func TestCronScheduler_Update_Failure(t *testing.T) {
+	first := &countingServiceMock{}
+	second := &countingServiceMock{}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, err := MakeScheduler([]ScheduledCheck{{Service: first, Schedule: Schedule{Interval: time.Second}}}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	firstJob := scheduler.jobs[first].job
+	err = scheduler.Update([]ScheduledCheck{
+		{Service: first, Schedule: Schedule{Interval: 2 * time.Second}},
+		{Service: second, Schedule: Schedule{Cron: "invalid"}},
+	})
+	if err == nil {
+		t.Fatalf("Unexpected nil error")
+	}
+	if scheduler.jobs[first].job != firstJob || len(scheduler.jobs) != 1 || len(scheduler.scheduler.Jobs()) != 1 {
+		t.Errorf("Previous jobs should be kept after a failed update")
+	}
+}
+
+func TestCronScheduler_InitialDelayAndJitter(t *testing.T) {
+	service := countingServiceMock{duration: 0}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, err := MakeScheduler([]ScheduledCheck{{
+		Service: &service,
+		Schedule: Schedule{
+			Interval:     time.Minute,
+			InitialDelay: time.Minute,
+			Jitter:       10 * time.Millisecond,
+		},
+	}}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	scheduler.StartAsync()
+	time.Sleep(100 * time.Millisecond)
+	_ = scheduler.Shutdown(context.Background())
+	if service.checkCounter != 0 {
+		t.Fatalf("Service should not be checked before the initial delay, checked %d times", service.checkCounter)
+	}
+}
+
+func TestCronScheduler_Cron(t *testing.T) {
+	service := countingServiceMock{duration: 0}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, err := MakeScheduler([]ScheduledCheck{{Service: &service, Schedule: Schedule{Cron: "*/1 * * * * *"}}}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	if len(scheduler.scheduler.Jobs()) != 1 {
+		t.Errorf("Unexpected number of jobs %d", len(scheduler.scheduler.Jobs()))
+	}
+	_, err = MakeScheduler([]ScheduledCheck{{Service: &service, Schedule: Schedule{Cron: "not a cron"}}}, logger)
+	if err == nil {
+		t.Errorf("Error is expected for invalid cron expression")
+	}
+}
+
+func TestVerifyCronExpression(t *testing.T) {
+	for _, expression := range []string{"* * * * *", "*/10 * * * * *", "@every 1m"} {
+		if err := VerifyCronExpression(expression); err != nil {
+			t.Errorf("Unexpected error for '%s': %s", expression, err.Error())
+		}
+	}
+	if err := VerifyCronExpression("61 * * * *"); err == nil {
+		t.Errorf("Error is expected for invalid expression")
+	}
+}
+
+type slowServiceMock struct {
+	duration time.Duration
+	finished chan bool
+}
+
+func (s *slowServiceMock) Check(context.Context) error {
+	time.Sleep(s.duration)
+	s.finished <- true
+	return nil
+}
+
+func (s *slowServiceMock) Print() string {
+	return "slow service mock"
+}
+
+func TestCronScheduler_AwaitShutdown_InFlight(t *testing.T) {
+	service := slowServiceMock{duration: 300 * time.Millisecond, finished: make(chan bool, 1)}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, err := MakeScheduler([]ScheduledCheck{{Service: &service, Schedule: Schedule{Interval: time.Minute}}}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	scheduler.StartAsync()
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_ = scheduler.Shutdown(ctx)
+	err = scheduler.AwaitShutdown(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error during shutdown awaiting: '%s'", err.Error())
+	}
+	select {
+	case <-service.finished:
+	default:
+		t.Errorf("Check in flight should finish before the scheduler stops")
+	}
+}
+
+func TestCronScheduler_AwaitShutdown_GracePeriodExceeded(t *testing.T) {
+	service := slowServiceMock{duration: time.Second, finished: make(chan bool, 1)}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, _ := MakeScheduler([]ScheduledCheck{{Service: &service, Schedule: Schedule{Interval: time.Minute}}}, logger)
+	scheduler.StartAsync()
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = scheduler.Shutdown(ctx)
+	err := scheduler.AwaitShutdown(ctx)
+	if err == nil {
+		t.Fatalf("Error is expected when checks do not finish within the grace period")
+	}
+}
+
+type blockingServiceMock struct {
+	errors chan error
+}
+
+func (s *blockingServiceMock) Check(ctx context.Context) error {
+	<-ctx.Done()
+	s.errors <- ctx.Err()
+	return ctx.Err()
+}
+
+func (s *blockingServiceMock) Print() string {
+	return "blocking service mock"
+}
+
+func TestCronScheduler_Timeout(t *testing.T) {
+	service := blockingServiceMock{errors: make(chan error, 1)}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, _ := MakeScheduler([]ScheduledCheck{{
+		Service:  &service,
+		Schedule: Schedule{Interval: time.Minute, Timeout: 50 * time.Millisecond},
+	}}, logger)
+	scheduler.StartAsync()
+	select {
+	case err := <-service.errors:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Check should be cancelled after the timeout")
+	}
+	_ = scheduler.Shutdown(context.Background())
+}
+
+func TestCronScheduler_AwaitShutdown_CancelsChecks(t *testing.T) {
+	service := blockingServiceMock{errors: make(chan error, 1)}
+	logger := logrus.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	scheduler, _ := MakeScheduler([]ScheduledCheck{{Service: &service, Schedule: Schedule{Interval: time.Minute}}}, logger)
+	scheduler.StartAsync()
+	time.Sleep(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_ = scheduler.Shutdown(ctx)
+	if err := scheduler.AwaitShutdown(ctx); err == nil {
+		t.Errorf("Error is expected when checks are cancelled")
+	}
+	select {
+	case err := <-service.errors:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Check in flight should be cancelled")
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+// DependencyRule applies its effect to the targets, while the condition is in the given state
+type DependencyRule struct {
+	Condition Fragile
+	WhenOk    bool
+	Effect    string
+	// All targets every fragile of the aggregation
+	All     bool
+	Targets []Fragile
+}
+
+func (rule DependencyRule) applies() bool {
+	return rule.Condition.IsOk() == rule.WhenOk
+}
+
+// evaluateRules returns the ignored and the failed fragiles, the ignored ones are not taken into account at all
+func evaluateRules(rules []DependencyRule, fragiles []Fragile) (map[Fragile]bool, map[Fragile]bool) {
+	ignored := map[Fragile]bool{}
+	failed := map[Fragile]bool{}
+	for _, rule := range rules {
+		if !rule.applies() {
+			continue
+		}
+		targets := rule.Targets
+		if rule.All {
+			targets = fragiles
+		}
+		for _, target := range targets {
+			if rule.Effect == RuleEffectIgnore {
+				ignored[target] = true
+			} else {
+				failed[target] = true
+			}
+		}
+	}
+	return ignored, failed
+}
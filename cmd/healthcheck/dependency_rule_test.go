@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestEvaluateRules(t *testing.T) {
+	up := &fragileStub{isOk: true}
+	down := &fragileStub{isOk: false}
+	first := &fragileStub{isOk: true}
+	second := &fragileStub{isOk: true}
+	ignored, failed := evaluateRules([]DependencyRule{
+		{Condition: up, WhenOk: true, Effect: RuleEffectIgnore, Targets: []Fragile{first}},
+		{Condition: down, WhenOk: false, Effect: RuleEffectFail, Targets: []Fragile{second}},
+		{Condition: down, WhenOk: true, Effect: RuleEffectIgnore, All: true},
+	}, []Fragile{first, second})
+	if len(ignored) != 1 || !ignored[first] {
+		t.Errorf("Unexpected ignored fragiles %v", ignored)
+	}
+	if len(failed) != 1 || !failed[second] {
+		t.Errorf("Unexpected failed fragiles %v", failed)
+	}
+	ignored, _ = evaluateRules([]DependencyRule{{Condition: down, Effect: RuleEffectIgnore, All: true}}, []Fragile{first, second})
+	if len(ignored) != 2 {
+		t.Errorf("Unexpected ignored fragiles %v", ignored)
+	}
+}
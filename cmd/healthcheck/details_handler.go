@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type serviceDetails struct {
+	Name      string  `json:"name"`
+	IsOk      bool    `json:"ok"`
+	Failures  int     `json:"failures"`
+	LastError string  `json:"last-error,omitempty"`
+	LastCheck string  `json:"last-check,omitempty"`
+	LatencyMs float64 `json:"last-latency-ms"`
+	// DaysToExpiry is only present for certificate checks
+	DaysToExpiry *float64 `json:"days-to-expiry,omitempty"`
+}
+
+type healthDetails struct {
+	Namespace string           `json:"namespace"`
+	Services  []serviceDetails `json:"services"`
+}
+
+type DetailsHandler struct {
+	namespace string
+	mutex     sync.RWMutex
+	reporters []Reporter
+}
+
+func MakeDetailsHandler(namespace string, reporters []Reporter) *DetailsHandler {
+	return &DetailsHandler{
+		namespace: namespace,
+		reporters: reporters,
+	}
+}
+
+func (dh *DetailsHandler) Update(reporters []Reporter) {
+	dh.mutex.Lock()
+	defer dh.mutex.Unlock()
+	dh.reporters = reporters
+}
+
+func (dh *DetailsHandler) collect() healthDetails {
+	dh.mutex.RLock()
+	defer dh.mutex.RUnlock()
+	details := healthDetails{
+		Namespace: dh.namespace,
+		Services:  []serviceDetails{},
+	}
+	for _, reporter := range dh.reporters {
+		report := reporter.Report()
+		service := serviceDetails{
+			Name:         report.Name,
+			IsOk:         report.IsOk,
+			Failures:     report.Failures,
+			LastError:    report.LastError,
+			LatencyMs:    float64(report.LastLatency) / float64(time.Millisecond),
+			DaysToExpiry: report.DaysToExpiry,
+		}
+		if !report.LastCheck.IsZero() {
+			service.LastCheck = report.LastCheck.UTC().Format(time.RFC3339Nano)
+		}
+		details.Services = append(details.Services, service)
+	}
+	return details
+}
+
+func (dh *DetailsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	response, err := json.Marshal(dh.collect())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
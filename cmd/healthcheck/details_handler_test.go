@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type reporterStub struct {
+	report ServiceReport
+}
+
+func (s *reporterStub) Report() ServiceReport { return s.report }
+
+func TestDetailsHandler_ServeHTTP(t *testing.T) {
+	lastCheck := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler := MakeDetailsHandler("x-namespace-x", []Reporter{
+		&reporterStub{report: ServiceReport{Name: "healthy", IsOk: true}},
+		&reporterStub{report: ServiceReport{
+			Name:        "broken",
+			IsOk:        false,
+			Failures:    4,
+			LastError:   "connection refused",
+			LastCheck:   lastCheck,
+			LastLatency: 1500 * time.Microsecond,
+		}},
+	})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodGet})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+	if rr.Header().Get("Content-Type") != "application/json" {
+		t.Error("Unexpected Content-Type in the response")
+	}
+	var resp healthDetails
+	err := json.NewDecoder(rr.Body).Decode(&resp)
+	if err != nil {
+		t.Fatalf("Unexpected error during parsing of the response body: '%s'", err.Error())
+	}
+	if resp.Namespace != "x-namespace-x" {
+		t.Errorf("Unexpected namespace '%s' in the body", resp.Namespace)
+	}
+	if len(resp.Services) != 2 {
+		t.Fatalf("Unexpected number of services %d in the body", len(resp.Services))
+	}
+	healthy := resp.Services[0]
+	if healthy.Name != "healthy" || !healthy.IsOk || healthy.LastCheck != "" || healthy.LastError != "" {
+		t.Errorf("Unexpected details of the healthy service: %+v", healthy)
+	}
+	broken := resp.Services[1]
+	if broken.Name != "broken" || broken.IsOk || broken.Failures != 4 {
+		t.Errorf("Unexpected details of the broken service: %+v", broken)
+	}
+	if broken.LastError != "connection refused" {
+		t.Errorf("Unexpected last error '%s'", broken.LastError)
+	}
+	if broken.LastCheck != "2022-01-02T03:04:05Z" {
+		t.Errorf("Unexpected last check '%s'", broken.LastCheck)
+	}
+	if broken.LatencyMs != 1.5 {
+		t.Errorf("Unexpected latency %f", broken.LatencyMs)
+	}
+}
+
+func TestDetailsHandler_ServeHTTP_NoServices(t *testing.T) {
+	handler := MakeDetailsHandler("", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodGet})
+	if rr.Body.String() != `{"namespace":"","services":[]}` {
+		t.Errorf("Unexpected body '%s'", rr.Body.String())
+	}
+}
+
+func TestDetailsHandler_ServeHTTP_InvalidMethod(t *testing.T) {
+	handler := MakeDetailsHandler("", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodPost})
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
+import "context"
+
 type FragileService interface {
 	IsOk() bool
-	Check() error
+	Check(ctx context.Context) error
 	Print() string
+	Report() ServiceReport
+	AddObserver(observer CheckObserver)
 }
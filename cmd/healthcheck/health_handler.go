@@ -4,19 +4,46 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 )
 
+type HealthStatus int
+
+const (
+	StatusSuccess HealthStatus = iota
+	StatusDegraded
+	StatusError
+)
+
+func (status HealthStatus) String() string {
+	switch status {
+	case StatusSuccess:
+		return "success"
+	case StatusDegraded:
+		return "degraded"
+	default:
+		return "error"
+	}
+}
+
+// HealthHandler reports an error if any of the critical fragiles is not OK,
+// failures of the optional fragiles only degrade the status, the rules may ignore or fail some of the fragiles
 type HealthHandler struct {
-	success  []byte
-	error    []byte
-	geo      Fragile
-	fragiles []Fragile
+	namespace string
+	success   []byte
+	degraded  []byte
+	error     []byte
+	mutex     sync.RWMutex
+	fragiles  []Fragile
+	optional  []Fragile
+	rules     []DependencyRule
 }
 
 func MakeHealthHandler(
 	namespace string,
 	fragiles []Fragile,
-	geo Fragile) (*HealthHandler, error) {
+	optional []Fragile,
+	rules []DependencyRule) (*HealthHandler, error) {
 	successString, err := json.Marshal(map[string]string{
 		"status":    "success",
 		"code":      "200",
@@ -25,6 +52,14 @@ func MakeHealthHandler(
 	if err != nil {
 		return nil, fmt.Errorf("can not marshal sucess response: %w", err)
 	}
+	degradedString, err := json.Marshal(map[string]string{
+		"status":    "degraded",
+		"code":      "200",
+		"namespace": namespace,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can not marshal degraded response: %w", err)
+	}
 	errorString, err := json.Marshal(map[string]string{
 		"status":    "error",
 		"code":      "500",
@@ -34,43 +69,134 @@ func MakeHealthHandler(
 		return nil, fmt.Errorf("can not marshal error response: %w", err)
 	}
 	return &HealthHandler{
-		success:  successString,
-		error:    errorString,
-		geo:      geo,
-		fragiles: fragiles,
+		namespace: namespace,
+		success:   successString,
+		degraded:  degradedString,
+		error:     errorString,
+		fragiles:  fragiles,
+		optional:  optional,
+		rules:     rules,
 	}, nil
 }
 
-func (hh *HealthHandler) isOk() bool {
-	if hh.geo != nil && !hh.geo.IsOk() {
-		return true
+func (hh *HealthHandler) Update(fragiles []Fragile, optional []Fragile, rules []DependencyRule) {
+	hh.mutex.Lock()
+	defer hh.mutex.Unlock()
+	hh.fragiles = fragiles
+	hh.optional = optional
+	hh.rules = rules
+}
+
+type fragileState struct {
+	fragile Fragile
+	status  HealthStatus
+	// effect is the effect of the rules, which apply to the fragile, if any
+	effect string
+}
+
+// evaluate applies the rules to the critical and the optional fragiles, a failed optional fragile only degrades the status
+func (hh *HealthHandler) evaluate() (HealthStatus, []fragileState) {
+	hh.mutex.RLock()
+	defer hh.mutex.RUnlock()
+	all := append(append([]Fragile{}, hh.fragiles...), hh.optional...)
+	ignored, failed := evaluateRules(hh.rules, all)
+	status := StatusSuccess
+	var states []fragileState
+	for i, fragiles := range [][]Fragile{hh.fragiles, hh.optional} {
+		failure := StatusError
+		if i > 0 {
+			failure = StatusDegraded
+		}
+		for _, fragile := range fragiles {
+			state := fragileState{fragile: fragile, status: StatusSuccess}
+			switch {
+			case ignored[fragile]:
+				state.effect = RuleEffectIgnore
+			case failed[fragile]:
+				state.effect = RuleEffectFail
+				state.status = failure
+			case !fragile.IsOk():
+				state.status = failure
+			}
+			if state.status > status {
+				status = state.status
+			}
+			states = append(states, state)
+		}
+	}
+	return status, states
+}
+
+func (hh *HealthHandler) Status() HealthStatus {
+	status, _ := hh.evaluate()
+	return status
+}
+
+// IsOk is true for a degraded status as well
+func (hh *HealthHandler) IsOk() bool {
+	return hh.Status() != StatusError
+}
+
+// checks lists the fragiles, which can report about themselves
+func checks(states []fragileState) []healthCheck {
+	var result []healthCheck
+	for _, state := range states {
+		if reporter, ok := state.fragile.(Reporter); ok {
+			result = append(result, healthCheck{report: reporter.Report(), status: state.status, effect: state.effect})
+		}
 	}
-	isOk := true
-	for _, fragile := range hh.fragiles {
-		isOk = isOk && fragile.IsOk()
+	return result
+}
+
+func (hh *HealthHandler) getCurrentResponse(status HealthStatus) []byte {
+	switch status {
+	case StatusSuccess:
+		return hh.success
+	case StatusDegraded:
+		return hh.degraded
+	default:
+		return hh.error
 	}
-	return isOk
 }
 
-func (hh *HealthHandler) getCurrentResponse() ([]byte, int) {
-	if hh.isOk() {
-		return hh.success, http.StatusOK
-	} else {
-		return hh.error, http.StatusInternalServerError
+func (status HealthStatus) statusCode() int {
+	if status == StatusError {
+		return http.StatusInternalServerError
 	}
+	return http.StatusOK
 }
 
+// ServeHTTP falls back to json for the clients, which accept anything
 func (hh *HealthHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
-	if req.Header.Get("Accept") != "application/json" {
+	w.Header().Set("Vary", "Accept")
+	mediaType, ok := negotiate(req.Header.Get("Accept"), []string{MediaTypeJson, MediaTypeHealthJson, MediaTypeText, MediaTypeHtml})
+	if !ok {
 		w.WriteHeader(http.StatusNotAcceptable)
 		return
 	}
-	response, statusCode := hh.getCurrentResponse()
-	w.WriteHeader(statusCode)
-	w.Header().Set("Content-Type", "application/json")
+	status, states := hh.evaluate()
+	var response []byte
+	var err error
+	switch mediaType {
+	case MediaTypeHealthJson:
+		response, err = renderHealthJson(hh.namespace, status, checks(states))
+	case MediaTypeText:
+		response = renderText(status)
+	case MediaTypeHtml:
+		mediaType += "; charset=utf-8"
+		response, err = renderHtml(hh.namespace, status, checks(states))
+	default:
+		response = hh.getCurrentResponse(status)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status.statusCode())
 	_, _ = w.Write(response)
 }
@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 type fragileStub struct {
@@ -15,7 +17,7 @@ func (s *fragileStub) IsOk() bool { return s.isOk }
 
 func TestMakeHealthHandler(t *testing.T) {
 	services := []Fragile{&fragileStub{isOk: true}}
-	handler, err := MakeHealthHandler("", services, nil)
+	handler, err := MakeHealthHandler("", services, nil, nil)
 	if err != nil {
 		t.Error("Unexpected error occurred during health handler initialization")
 	}
@@ -28,7 +30,7 @@ func TestMakeHealthHandler(t *testing.T) {
 }
 
 func TestHealthHandler_ServeHTTP_ValidRequest_ServicesOk(t *testing.T) {
-	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{&fragileStub{isOk: true}}, nil)
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{&fragileStub{isOk: true}}, nil, nil)
 	rr := httptest.NewRecorder()
 
 	handler.ServeHTTP(rr, &http.Request{
@@ -61,7 +63,7 @@ func TestHealthHandler_ServeHTTP_ValidRequest_ServicesOk(t *testing.T) {
 }
 
 func TestHealthHandler_ServeHTTP_ValidRequest_ServicesNotOk(t *testing.T) {
-	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{&fragileStub{isOk: false}}, nil)
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{&fragileStub{isOk: false}}, nil, nil)
 	rr := httptest.NewRecorder()
 
 	handler.ServeHTTP(rr, &http.Request{
@@ -93,8 +95,65 @@ func TestHealthHandler_ServeHTTP_ValidRequest_ServicesNotOk(t *testing.T) {
 	}
 }
 
+func TestHealthHandler_ServeHTTP_ValidRequest_OptionalServicesNotOk(t *testing.T) {
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{&fragileStub{isOk: true}}, []Fragile{&fragileStub{isOk: false}}, nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{
+		Method: http.MethodGet,
+		Header: http.Header{
+			http.CanonicalHeaderKey("accept"): []string{"application/json"},
+		},
+	})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+	var resp map[string]string
+	err := json.NewDecoder(rr.Body).Decode(&resp)
+	if err != nil {
+		t.Errorf("Unexpected error during parsion of the response body: '%s'", err.Error())
+	}
+	if resp["code"] != "200" {
+		t.Errorf("Unexpected code '%s' in the body, expected='%s'", resp["code"], "200")
+	}
+	if resp["status"] != "degraded" {
+		t.Errorf("Unexpected status '%s' in the body, expected='%s'", resp["status"], "degraded")
+	}
+}
+
+func geoRule(geo Fragile) DependencyRule {
+	return DependencyRule{Condition: geo, WhenOk: false, Effect: RuleEffectIgnore, All: true}
+}
+
+func TestHealthHandler_Status(t *testing.T) {
+	ok := &fragileStub{isOk: true}
+	notOk := &fragileStub{isOk: false}
+	for _, testCase := range []struct {
+		fragiles []Fragile
+		optional []Fragile
+		rules    []DependencyRule
+		expected HealthStatus
+	}{
+		{[]Fragile{ok}, []Fragile{ok}, nil, StatusSuccess},
+		{[]Fragile{ok}, []Fragile{notOk}, nil, StatusDegraded},
+		{[]Fragile{notOk}, []Fragile{notOk}, nil, StatusError},
+		{[]Fragile{notOk}, []Fragile{notOk}, []DependencyRule{geoRule(notOk)}, StatusSuccess},
+		{[]Fragile{ok}, []Fragile{notOk}, []DependencyRule{geoRule(ok)}, StatusDegraded},
+		{[]Fragile{ok, notOk}, nil, []DependencyRule{{Condition: ok, WhenOk: true, Effect: RuleEffectIgnore, Targets: []Fragile{notOk}}}, StatusSuccess},
+		{[]Fragile{ok}, []Fragile{ok}, []DependencyRule{{Condition: notOk, Effect: RuleEffectFail, Targets: []Fragile{ok}}}, StatusError},
+	} {
+		handler, _ := MakeHealthHandler("", testCase.fragiles, testCase.optional, testCase.rules)
+		if status := handler.Status(); status != testCase.expected {
+			t.Errorf("Unexpected status '%s', expected='%s'", status, testCase.expected)
+		}
+		if handler.IsOk() != (testCase.expected != StatusError) {
+			t.Errorf("Unexpected aggregated state for status '%s'", testCase.expected)
+		}
+	}
+}
+
 func TestHealthHandler_ServeHTTP_InvalidMethod(t *testing.T) {
-	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{}, nil)
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{}, nil, nil)
 	rr := httptest.NewRecorder()
 
 	for _, method := range []string{
@@ -120,7 +179,7 @@ func TestHealthHandler_ServeHTTP_InvalidMethod(t *testing.T) {
 }
 
 func TestHealthHandler_ServeHTTP_InvalidAccept(t *testing.T) {
-	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{}, nil)
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{}, nil, nil)
 	rr := httptest.NewRecorder()
 
 	handler.ServeHTTP(rr, &http.Request{
@@ -134,3 +193,120 @@ func TestHealthHandler_ServeHTTP_InvalidAccept(t *testing.T) {
 		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusMethodNotAllowed)
 	}
 }
+
+type reportingFragileStub struct {
+	report ServiceReport
+}
+
+func (s *reportingFragileStub) IsOk() bool            { return s.report.IsOk }
+func (s *reportingFragileStub) Report() ServiceReport { return s.report }
+
+func serveHealth(handler *HealthHandler, accept string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/health", nil)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	handler.ServeHTTP(rr, request)
+	return rr
+}
+
+func TestHealthHandler_ServeHTTP_Negotiation(t *testing.T) {
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{&fragileStub{isOk: true}}, nil, nil)
+	for _, testCase := range []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json", `{"code":"200","namespace":"x-namespace-x","status":"success"}`},
+		{"application/json, */*", "application/json", `{"code":"200","namespace":"x-namespace-x","status":"success"}`},
+		{"text/plain", "text/plain", "success\n"},
+		{"application/health+json", "application/health+json", `{"status":"pass","description":"health of namespace 'x-namespace-x'"}`},
+	} {
+		rr := serveHealth(handler, testCase.accept)
+		if rr.Code != http.StatusOK {
+			t.Errorf("Unexpected status code %d for '%s'", rr.Code, testCase.accept)
+		}
+		if rr.Header().Get("Content-Type") != testCase.contentType || rr.Header().Get("Vary") != "Accept" {
+			t.Errorf("Unexpected headers %v for '%s'", rr.Header(), testCase.accept)
+		}
+		if rr.Body.String() != testCase.body {
+			t.Errorf("Unexpected body '%s' for '%s', expected='%s'", rr.Body.String(), testCase.accept, testCase.body)
+		}
+	}
+}
+
+func TestHealthHandler_ServeHTTP_HealthJson(t *testing.T) {
+	lastCheck := time.Date(2021, 5, 1, 10, 0, 0, 0, time.UTC)
+	critical := &reportingFragileStub{ServiceReport{Name: "critical", IsOk: false, LastError: "refused", LastCheck: lastCheck, LastLatency: 1500 * time.Microsecond}}
+	optional := &reportingFragileStub{ServiceReport{Name: "optional", IsOk: false}}
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{critical, &fragileStub{isOk: true}}, []Fragile{optional}, nil)
+
+	rr := serveHealth(handler, "application/health+json")
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Unexpected status code %d, expected=%d", rr.Code, http.StatusInternalServerError)
+	}
+	expected := `{"status":"fail","description":"health of namespace 'x-namespace-x'","checks":{` +
+		`"critical":[{"status":"fail","observedValue":1.5,"observedUnit":"ms","time":"2021-05-01T10:00:00Z","output":"refused"}],` +
+		`"optional":[{"status":"warn"}]}}`
+	if rr.Body.String() != expected {
+		t.Errorf("Unexpected body '%s', expected='%s'", rr.Body.String(), expected)
+	}
+}
+
+func TestHealthHandler_ServeHTTP_Html(t *testing.T) {
+	critical := &reportingFragileStub{ServiceReport{Name: "<critical>", IsOk: true, LastCheck: time.Now()}}
+	optional := &reportingFragileStub{ServiceReport{Name: "optional", IsOk: false, LastError: "refused"}}
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{critical}, []Fragile{optional}, nil)
+
+	rr := serveHealth(handler, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("Unexpected response %d %v", rr.Code, rr.Header())
+	}
+	body := rr.Body.String()
+	for _, expected := range []string{
+		`x-namespace-x is <span class="degraded">degraded</span>`,
+		`<td>&lt;critical&gt;</td><td class="success">success</td>`,
+		`<td>optional</td><td class="degraded">degraded</td><td></td><td></td><td>refused</td>`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Page does not contain '%s':\n%s", expected, body)
+		}
+	}
+}
+
+func TestHealthHandler_ServeHTTP_ChecksFollowRules(t *testing.T) {
+	geo := &reportingFragileStub{ServiceReport{Name: "geo", IsOk: false, LastError: "refused"}}
+	svc := &reportingFragileStub{ServiceReport{Name: "svc", IsOk: false}}
+	handler, _ := MakeHealthHandler("x-namespace-x", []Fragile{geo, svc}, nil, []DependencyRule{geoRule(geo)})
+
+	rr := serveHealth(handler, "application/health+json")
+	if rr.Code != http.StatusOK {
+		t.Errorf("Unexpected status code %d, expected=%d", rr.Code, http.StatusOK)
+	}
+	expected := `{"status":"pass","description":"health of namespace 'x-namespace-x'","checks":{` +
+		`"geo":[{"status":"pass","output":"ignored by a dependency rule: refused"}],` +
+		`"svc":[{"status":"pass","output":"ignored by a dependency rule"}]}}`
+	if rr.Body.String() != expected {
+		t.Errorf("Unexpected body '%s', expected='%s'", rr.Body.String(), expected)
+	}
+	body := serveHealth(handler, "text/html").Body.String()
+	if !strings.Contains(body, `<td>svc</td><td class="success">ignored</td>`) {
+		t.Errorf("Ignored service should be marked in the page:\n%s", body)
+	}
+
+	other := &reportingFragileStub{ServiceReport{Name: "other", IsOk: true}}
+	optional := &reportingFragileStub{ServiceReport{Name: "optional", IsOk: true}}
+	rule := DependencyRule{Condition: other, WhenOk: true, Effect: RuleEffectFail, Targets: []Fragile{other, optional}}
+	handler, _ = MakeHealthHandler("x-namespace-x", []Fragile{other}, []Fragile{optional}, []DependencyRule{rule})
+	rr = serveHealth(handler, "application/health+json")
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Unexpected status code %d, expected=%d", rr.Code, http.StatusInternalServerError)
+	}
+	expected = `{"status":"fail","description":"health of namespace 'x-namespace-x'","checks":{` +
+		`"optional":[{"status":"warn","output":"failed by a dependency rule"}],` +
+		`"other":[{"status":"fail","output":"failed by a dependency rule"}]}}`
+	if rr.Body.String() != expected {
+		t.Errorf("Unexpected body '%s', expected='%s'", rr.Body.String(), expected)
+	}
+}
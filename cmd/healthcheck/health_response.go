@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"time"
+)
+
+type healthCheck struct {
+	report ServiceReport
+	status HealthStatus
+	effect string
+}
+
+func (check healthCheck) output() string {
+	switch check.effect {
+	case RuleEffectIgnore:
+		if check.report.LastError != "" {
+			return "ignored by a dependency rule: " + check.report.LastError
+		}
+		return "ignored by a dependency rule"
+	case RuleEffectFail:
+		return "failed by a dependency rule"
+	default:
+		return check.report.LastError
+	}
+}
+
+func (check healthCheck) statusText() string {
+	if check.effect == RuleEffectIgnore {
+		return "ignored"
+	}
+	return check.status.String()
+}
+
+// checkResponse follows the check object of the health check response format draft
+type checkResponse struct {
+	Status        string   `json:"status"`
+	ObservedValue *float64 `json:"observedValue,omitempty"`
+	ObservedUnit  string   `json:"observedUnit,omitempty"`
+	Time          string   `json:"time,omitempty"`
+	Output        string   `json:"output,omitempty"`
+}
+
+// healthResponse follows https://datatracker.ietf.org/doc/html/draft-inadarei-api-health-check
+type healthResponse struct {
+	Status      string                     `json:"status"`
+	Description string                     `json:"description"`
+	Checks      map[string][]checkResponse `json:"checks,omitempty"`
+}
+
+func (status HealthStatus) draftStatus() string {
+	switch status {
+	case StatusSuccess:
+		return "pass"
+	case StatusDegraded:
+		return "warn"
+	default:
+		return "fail"
+	}
+}
+
+func renderHealthJson(namespace string, status HealthStatus, checks []healthCheck) ([]byte, error) {
+	response := healthResponse{
+		Status:      status.draftStatus(),
+		Description: fmt.Sprintf("health of namespace '%s'", namespace),
+		Checks:      map[string][]checkResponse{},
+	}
+	for _, check := range checks {
+		result := checkResponse{Status: check.status.draftStatus(), Output: check.output()}
+		if !check.report.LastCheck.IsZero() {
+			latency := float64(check.report.LastLatency) / float64(time.Millisecond)
+			result.ObservedValue = &latency
+			result.ObservedUnit = "ms"
+			result.Time = check.report.LastCheck.UTC().Format(time.RFC3339)
+		}
+		response.Checks[check.report.Name] = append(response.Checks[check.report.Name], result)
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("can not marshal health response: %w", err)
+	}
+	return body, nil
+}
+
+func renderText(status HealthStatus) []byte {
+	return []byte(status.String() + "\n")
+}
+
+var healthPage = template.Must(template.New("health").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} - {{.Namespace}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+td, th { padding: 0.3em 1em; text-align: left; }
+.success { color: #2e7d32; } .degraded { color: #ef6c00; } .error { color: #c62828; }
+</style>
+</head>
+<body>
+<h1>{{.Namespace}} is <span class="{{.Status}}">{{.Status}}</span></h1>
+{{if .Checks}}<table>
+<tr><th>Service</th><th>Status</th><th>Last check</th><th>Latency, ms</th><th>Output</th></tr>
+{{range .Checks}}<tr><td>{{.Name}}</td><td class="{{.Class}}">{{.Status}}</td><td>{{.LastCheck}}</td><td>{{.LatencyMs}}</td><td>{{.LastError}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+type htmlCheck struct {
+	Name      string
+	Class     string
+	Status    string
+	LastCheck string
+	LatencyMs string
+	LastError string
+}
+
+func renderHtml(namespace string, status HealthStatus, checks []healthCheck) ([]byte, error) {
+	page := struct {
+		Namespace string
+		Status    string
+		Checks    []htmlCheck
+	}{Namespace: namespace, Status: status.String()}
+	for _, check := range checks {
+		row := htmlCheck{Name: check.report.Name, Class: check.status.String(), Status: check.statusText(), LastError: check.output()}
+		if !check.report.LastCheck.IsZero() {
+			row.LastCheck = check.report.LastCheck.UTC().Format(time.RFC3339)
+			row.LatencyMs = fmt.Sprintf("%.1f", float64(check.report.LastLatency)/float64(time.Millisecond))
+		}
+		page.Checks = append(page.Checks, row)
+	}
+	buffer := bytes.Buffer{}
+	if err := healthPage.Execute(&buffer, page); err != nil {
+		return nil, fmt.Errorf("can not render health page: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
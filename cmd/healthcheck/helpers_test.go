@@ -1,15 +1,26 @@
 package main
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+)
 
 type ServiceStub struct {
 	Err error
 }
 
-func (s *ServiceStub) Check() error {
+func (s *ServiceStub) Check(context.Context) error {
 	return s.Err
 }
 
 func (s *ServiceStub) Print() string {
 	return fmt.Sprintf("service stub")
 }
+
+func silentLogger() *log.Logger {
+	logger := log.New()
+	logger.SetOutput(ioutil.Discard)
+	return logger
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultHistorySize = 100
+
+type historyEntry struct {
+	Time      string  `json:"time"`
+	Success   bool    `json:"success"`
+	IsOk      bool    `json:"ok"`
+	LatencyMs float64 `json:"latency-ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type serviceHistoryResponse struct {
+	Name          string         `json:"name"`
+	Type          string         `json:"type"`
+	Endpoint      string         `json:"endpoint"`
+	UptimePercent *float64       `json:"uptime-percent,omitempty"`
+	Checks        []historyEntry `json:"checks"`
+}
+
+type historyResponse struct {
+	Namespace string                   `json:"namespace"`
+	Services  []serviceHistoryResponse `json:"services"`
+}
+
+// serviceHistory keeps the last results of a service in a ring buffer
+type serviceHistory struct {
+	service  FragileService
+	identity serviceIdentity
+	mutex    sync.Mutex
+	results  []CheckResult
+	next     int
+	full     bool
+}
+
+func makeServiceHistory(service FragileService, identity serviceIdentity, size int) *serviceHistory {
+	return &serviceHistory{
+		service:  service,
+		identity: identity,
+		results:  make([]CheckResult, size),
+	}
+}
+
+func (sh *serviceHistory) observed() FragileService {
+	return sh.service
+}
+
+func (sh *serviceHistory) Observe(result CheckResult) {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	sh.results[sh.next] = result
+	sh.next = (sh.next + 1) % len(sh.results)
+	if sh.next == 0 {
+		sh.full = true
+	}
+}
+
+// Results returns the retained results from the oldest to the newest
+func (sh *serviceHistory) Results() []CheckResult {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	if !sh.full {
+		return append([]CheckResult{}, sh.results[:sh.next]...)
+	}
+	return append(append([]CheckResult{}, sh.results[sh.next:]...), sh.results[:sh.next]...)
+}
+
+func (sh *serviceHistory) collect() serviceHistoryResponse {
+	results := sh.Results()
+	response := serviceHistoryResponse{
+		Name:     sh.identity.name,
+		Type:     sh.identity.kind,
+		Endpoint: sh.identity.endpoint,
+		Checks:   []historyEntry{},
+	}
+	successes := 0
+	for _, result := range results {
+		entry := historyEntry{
+			Time:      result.Time.UTC().Format(time.RFC3339Nano),
+			Success:   result.Err == nil,
+			IsOk:      result.IsOk,
+			LatencyMs: float64(result.Latency) / float64(time.Millisecond),
+		}
+		if result.Err != nil {
+			entry.Error = result.Err.Error()
+		} else {
+			successes++
+		}
+		response.Checks = append(response.Checks, entry)
+	}
+	if len(results) > 0 {
+		uptime := 100 * float64(successes) / float64(len(results))
+		response.UptimePercent = &uptime
+	}
+	return response
+}
+
+// HistoryHandler exposes the last results of the checks, the services can be filtered by their configured names
+// with the 'service' query parameter
+type HistoryHandler struct {
+	namespace string
+	size      int
+	services  observedServices
+}
+
+func MakeHistoryHandler(namespace string, size int) *HistoryHandler {
+	if size <= 0 {
+		size = defaultHistorySize
+	}
+	return &HistoryHandler{
+		namespace: namespace,
+		size:      size,
+	}
+}
+
+func (hh *HistoryHandler) Register(service FragileService, identity serviceIdentity) {
+	hh.services.register(makeServiceHistory(service, identity, hh.size))
+}
+
+// Update keeps the history of the services, which are still present
+func (hh *HistoryHandler) Update(services []FragileService, identities map[FragileService]serviceIdentity) {
+	hh.services.update(services, func(service FragileService) serviceObserver {
+		return makeServiceHistory(service, identities[service], hh.size)
+	})
+}
+
+func (hh *HistoryHandler) collect(name string) historyResponse {
+	response := historyResponse{
+		Namespace: hh.namespace,
+		Services:  []serviceHistoryResponse{},
+	}
+	for _, observer := range hh.services.snapshot() {
+		history := observer.(*serviceHistory)
+		if name != "" && history.identity.name != name {
+			continue
+		}
+		response.Services = append(response.Services, history.collect())
+	}
+	return response
+}
+
+func (hh *HistoryHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	name := req.URL.Query().Get("service")
+	collected := hh.collect(name)
+	if name != "" && len(collected.Services) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	response, err := json.Marshal(collected)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestServiceHistory_Results(t *testing.T) {
+	history := makeServiceHistory(&fragileServiceStub{}, serviceIdentity{name: "stub"}, 3)
+	if len(history.Results()) != 0 {
+		t.Errorf("Unexpected results in an empty history")
+	}
+	for i := 0; i < 5; i++ {
+		history.Observe(CheckResult{Latency: milliseconds(5 * i)})
+	}
+	results := history.Results()
+	if len(results) != 3 {
+		t.Fatalf("Unexpected number of results %d", len(results))
+	}
+	for i, result := range results {
+		if result.Latency != milliseconds(5*(i+2)) {
+			t.Errorf("Unexpected latency %s at %d", result.Latency, i)
+		}
+	}
+}
+
+func TestHistoryHandler_ServeHTTP(t *testing.T) {
+	service := ServiceStub{}
+	proxy := MakeHopefulProxy(&service, 0, 1, silentLogger())
+	handler := MakeHistoryHandler("x-namespace-x", 10)
+	handler.Register(proxy, serviceIdentity{name: "stub", kind: ServiceTypeHttp, endpoint: "http://stub:80/health"})
+	for i := 0; i < 3; i++ {
+		_ = proxy.Check(context.Background())
+	}
+	service.Err = errors.New("error")
+	_ = proxy.Check(context.Background())
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodGet, URL: &url.URL{}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+	var response historyResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if response.Namespace != "x-namespace-x" || len(response.Services) != 1 {
+		t.Fatalf("Unexpected response %+v", response)
+	}
+	history := response.Services[0]
+	if history.Name != "stub" || history.Type != ServiceTypeHttp || history.Endpoint != "http://stub:80/health" {
+		t.Errorf("Unexpected identity of the history %+v", history)
+	}
+	if history.UptimePercent == nil || *history.UptimePercent != 75 {
+		t.Errorf("Unexpected uptime %v", history.UptimePercent)
+	}
+	if len(history.Checks) != 4 {
+		t.Fatalf("Unexpected number of checks %d", len(history.Checks))
+	}
+	last := history.Checks[3]
+	if last.Success || last.IsOk || last.Error != "error" {
+		t.Errorf("Unexpected last check %+v", last)
+	}
+}
+
+func TestHistoryHandler_ServeHTTP_Filter(t *testing.T) {
+	handler := MakeHistoryHandler("", 0)
+	handler.Register(MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger()), serviceIdentity{name: "stub"})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodGet, URL: &url.URL{RawQuery: "service=unknown"}})
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusNotFound)
+	}
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodGet, URL: &url.URL{RawQuery: "service=stub"}})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+}
+
+func TestHistoryHandler_Update(t *testing.T) {
+	retained := MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger())
+	handler := MakeHistoryHandler("", 0)
+	handler.Register(retained, serviceIdentity{name: "retained"})
+	handler.Register(MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger()), serviceIdentity{name: "removed"})
+	_ = retained.Check(context.Background())
+	added := MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger())
+	handler.Update([]FragileService{retained, added}, map[FragileService]serviceIdentity{retained: {name: "retained"}, added: {name: "added"}})
+	histories := handler.services.snapshot()
+	if len(histories) != 2 {
+		t.Fatalf("Unexpected number of services %d", len(histories))
+	}
+	if len(histories[0].(*serviceHistory).Results()) != 1 {
+		t.Errorf("Unexpected history of the retained service")
+	}
+	if name := histories[1].(*serviceHistory).identity.name; name != "added" {
+		t.Errorf("Unexpected name '%s' of the added service", name)
+	}
+}
+
+func TestHistoryHandler_ServeHTTP_InvalidMethod(t *testing.T) {
+	handler := MakeHistoryHandler("", 0)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodPost})
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -1,39 +1,87 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type HopefulProxy struct {
-	backend   Service
-	counter   int
-	threshold int
-	isOk      *atomic.Value
+	backend          Service
+	counter          int
+	threshold        int
+	successes        int
+	successThreshold int
+	isOk             *atomic.Value
+	mutex            sync.Mutex
+	lastError        error
+	lastCheck        time.Time
+	lastLatency      time.Duration
+	observers        []CheckObserver
+	logger           logrus.FieldLogger
 }
 
-func MakeHopefulProxy(backend Service, threshold int) *HopefulProxy {
+// MakeHopefulProxy makes a proxy, which is not OK after more than threshold consecutive failures
+// and becomes OK again after successThreshold consecutive successes
+func MakeHopefulProxy(backend Service, threshold int, successThreshold int, logger logrus.FieldLogger) *HopefulProxy {
 	isOk := atomic.Value{}
 	isOk.Store(true)
+	if successThreshold < 1 {
+		successThreshold = 1
+	}
 	return &HopefulProxy{
-		backend:   backend,
-		counter:   0,
-		threshold: threshold,
-		isOk:      &isOk,
+		backend:          backend,
+		counter:          0,
+		threshold:        threshold,
+		successThreshold: successThreshold,
+		isOk:             &isOk,
+		logger:           logger,
 	}
 }
 
-func (decor *HopefulProxy) Check() error {
-	err := decor.backend.Check()
+// Check does not change the state when the check is cancelled, e.g. on shutdown
+func (decor *HopefulProxy) Check(ctx context.Context) error {
+	started := time.Now()
+	err := decor.backend.Check(ctx)
+	latency := time.Since(started)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return err
+	}
+	decor.mutex.Lock()
+	wasOk := decor.IsOk()
+	decor.lastError = err
+	decor.lastCheck = started
+	decor.lastLatency = latency
 	if err != nil {
 		decor.failed()
 	} else {
 		decor.succeeded()
 	}
+	result := CheckResult{
+		Time:    started,
+		Latency: latency,
+		Err:     err,
+		WasOk:   wasOk,
+		IsOk:    decor.IsOk(),
+	}
+	observers := decor.observers
+	decor.mutex.Unlock()
+	for _, observer := range observers {
+		observer.Observe(result)
+	}
 	return err
 }
 
+func (decor *HopefulProxy) AddObserver(observer CheckObserver) {
+	decor.mutex.Lock()
+	defer decor.mutex.Unlock()
+	decor.observers = append(decor.observers, observer)
+}
+
 func (decor *HopefulProxy) IsOk() bool {
 	return decor.isOk.Load().(bool)
 }
@@ -42,18 +90,46 @@ func (decor *HopefulProxy) Print() string {
 	return fmt.Sprintf("watchful decorator for %s", decor.backend.Print())
 }
 
+func (decor *HopefulProxy) Report() ServiceReport {
+	decor.mutex.Lock()
+	defer decor.mutex.Unlock()
+	report := ServiceReport{
+		Name:        decor.Print(),
+		IsOk:        decor.IsOk(),
+		Failures:    decor.counter,
+		LastCheck:   decor.lastCheck,
+		LastLatency: decor.lastLatency,
+	}
+	if decor.lastError != nil {
+		report.LastError = decor.lastError.Error()
+	}
+	if expiring, ok := decor.backend.(Expiring); ok {
+		if days, known := expiring.DaysToExpiry(); known {
+			report.DaysToExpiry = &days
+		}
+	}
+	return report
+}
+
 func (decor *HopefulProxy) failed() {
-	if decor.counter >= decor.threshold {
+	decor.successes = 0
+	decor.counter += 1
+	if decor.counter > decor.threshold && decor.isOk.Load().(bool) {
+		decor.logger.WithFields(logrus.Fields{"service": decor.backend.Print(), "failures": decor.counter}).Warn("service is not OK now")
 		decor.isOk.Store(false)
-		return
 	}
-	decor.counter += 1
 }
 
 func (decor *HopefulProxy) succeeded() {
-	if !decor.isOk.Load().(bool) {
-		logrus.Infof("%s is OK now", decor.backend.Print())
+	decor.counter = 0
+	if decor.isOk.Load().(bool) {
+		return
+	}
+	decor.successes += 1
+	if decor.successes < decor.successThreshold {
+		return
 	}
+	decor.logger.WithFields(logrus.Fields{"service": decor.backend.Print(), "successes": decor.successes}).Info("service is OK now")
 	decor.isOk.Store(true)
-	decor.counter = 0
+	decor.successes = 0
 }
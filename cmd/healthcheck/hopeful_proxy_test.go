@@ -1,12 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	log "github.com/sirupsen/logrus"
 	"testing"
 )
 
 func TestMakeWatchfulDecorator(t *testing.T) {
-	decorator := MakeHopefulProxy(&ServiceStub{}, 3)
+	decorator := MakeHopefulProxy(&ServiceStub{}, 3, 1, silentLogger())
 	if decorator == nil {
 		t.Errorf("Decorator should not be nil")
 	}
@@ -15,9 +18,9 @@ func TestMakeWatchfulDecorator(t *testing.T) {
 func TestWatchfulDecorator_Lifecycle(t *testing.T) {
 	service := ServiceStub{}
 	threshold := 3
-	decorator := MakeHopefulProxy(&service, threshold)
+	decorator := MakeHopefulProxy(&service, threshold, 1, silentLogger())
 	for i := 0; i < 20; i++ {
-		err := decorator.Check()
+		err := decorator.Check(context.Background())
 		if err != nil {
 			t.Errorf("Unexpected error when backend does not return error")
 		}
@@ -27,7 +30,7 @@ func TestWatchfulDecorator_Lifecycle(t *testing.T) {
 	}
 	service.Err = errors.New("error")
 	for i := 0; i < threshold; i++ {
-		err := decorator.Check()
+		err := decorator.Check(context.Background())
 		if err == nil {
 			t.Errorf("Unexpected nil when service returns error")
 		}
@@ -35,7 +38,7 @@ func TestWatchfulDecorator_Lifecycle(t *testing.T) {
 			t.Errorf("Unexpected state of the decorator")
 		}
 	}
-	err := decorator.Check()
+	err := decorator.Check(context.Background())
 	if err == nil {
 		t.Errorf("Unexpected nil when service returns error")
 	}
@@ -44,7 +47,7 @@ func TestWatchfulDecorator_Lifecycle(t *testing.T) {
 	}
 	service.Err = nil
 	for i := 0; i < 20; i++ {
-		err := decorator.Check()
+		err := decorator.Check(context.Background())
 		if err != nil {
 			t.Errorf("Unexpected error when backend does not return error")
 		}
@@ -55,8 +58,135 @@ func TestWatchfulDecorator_Lifecycle(t *testing.T) {
 }
 
 func TestWatchfulDecorator_Print(t *testing.T) {
-	s := MakeHopefulProxy(&ServiceStub{}, 3).Print()
+	s := MakeHopefulProxy(&ServiceStub{}, 3, 1, silentLogger()).Print()
 	if s != "watchful decorator for service stub" {
 		t.Errorf("Unexpected description of the service")
 	}
 }
+
+func TestWatchfulDecorator_Report(t *testing.T) {
+	service := ServiceStub{}
+	decorator := MakeHopefulProxy(&service, 1, 1, silentLogger())
+	report := decorator.Report()
+	if !report.IsOk || !report.LastCheck.IsZero() || report.Failures != 0 {
+		t.Errorf("Unexpected report before any check: %+v", report)
+	}
+	service.Err = errors.New("error")
+	_ = decorator.Check(context.Background())
+	_ = decorator.Check(context.Background())
+	report = decorator.Report()
+	if report.Name != "watchful decorator for service stub" {
+		t.Errorf("Unexpected name '%s'", report.Name)
+	}
+	if report.IsOk {
+		t.Errorf("Unexpected state in the report")
+	}
+	if report.Failures != 2 {
+		t.Errorf("Unexpected number of failures %d", report.Failures)
+	}
+	if report.LastError != "error" {
+		t.Errorf("Unexpected last error '%s'", report.LastError)
+	}
+	if report.LastCheck.IsZero() {
+		t.Errorf("Last check time should be set")
+	}
+	service.Err = nil
+	_ = decorator.Check(context.Background())
+	report = decorator.Report()
+	if !report.IsOk || report.Failures != 0 || report.LastError != "" {
+		t.Errorf("Unexpected report after recovery: %+v", report)
+	}
+}
+
+type collectingObserver struct {
+	results []CheckResult
+}
+
+func (o *collectingObserver) Observe(result CheckResult) {
+	o.results = append(o.results, result)
+}
+
+func TestWatchfulDecorator_Observers(t *testing.T) {
+	service := ServiceStub{}
+	decorator := MakeHopefulProxy(&service, 0, 1, silentLogger())
+	observer := collectingObserver{}
+	decorator.AddObserver(&observer)
+	_ = decorator.Check(context.Background())
+	service.Err = errors.New("error")
+	_ = decorator.Check(context.Background())
+	if len(observer.results) != 2 {
+		t.Fatalf("Unexpected number of observed results %d", len(observer.results))
+	}
+	if !observer.results[0].WasOk || !observer.results[0].IsOk || observer.results[0].Err != nil {
+		t.Errorf("Unexpected first result: %+v", observer.results[0])
+	}
+	if !observer.results[1].WasOk || observer.results[1].IsOk || observer.results[1].Err == nil {
+		t.Errorf("Unexpected second result: %+v", observer.results[1])
+	}
+}
+
+func TestWatchfulDecorator_SuccessThreshold(t *testing.T) {
+	service := ServiceStub{Err: errors.New("error")}
+	decorator := MakeHopefulProxy(&service, 0, 3, silentLogger())
+	_ = decorator.Check(context.Background())
+	if decorator.IsOk() {
+		t.Fatalf("Unexpected state of the decorator after the threshold has been exceeded")
+	}
+	service.Err = nil
+	_ = decorator.Check(context.Background())
+	_ = decorator.Check(context.Background())
+	if decorator.IsOk() {
+		t.Errorf("Decorator should not recover before the success threshold is reached")
+	}
+	service.Err = errors.New("error")
+	_ = decorator.Check(context.Background())
+	service.Err = nil
+	_ = decorator.Check(context.Background())
+	_ = decorator.Check(context.Background())
+	if decorator.IsOk() {
+		t.Errorf("Failure should reset the number of consecutive successes")
+	}
+	_ = decorator.Check(context.Background())
+	if !decorator.IsOk() {
+		t.Errorf("Decorator should recover after the success threshold is reached")
+	}
+}
+
+func TestWatchfulDecorator_Cancelled(t *testing.T) {
+	service := ServiceStub{Err: context.Canceled}
+	decorator := MakeHopefulProxy(&service, 0, 1, silentLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := decorator.Check(ctx)
+	if err == nil {
+		t.Errorf("Unexpected nil when service returns error")
+	}
+	if !decorator.IsOk() || !decorator.Report().LastCheck.IsZero() {
+		t.Errorf("Cancelled check should not change the state of the decorator")
+	}
+}
+
+func TestWatchfulDecorator_Check_LogsTransitions(t *testing.T) {
+	logger := log.New()
+	formatter := collectingFormatter{entries: []*log.Entry{}}
+	logger.SetFormatter(&formatter)
+	logger.SetOutput(bytes.NewBufferString(""))
+	service := ServiceStub{Err: errors.New("error")}
+	decorator := MakeHopefulProxy(&service, 1, 1, logger)
+	for i := 0; i < 3; i++ {
+		_ = decorator.Check(context.Background())
+	}
+	service.Err = nil
+	_ = decorator.Check(context.Background())
+	if len(formatter.entries) != 2 {
+		t.Fatalf("Unexpected number of log entries %d, expected=%d", len(formatter.entries), 2)
+	}
+	down := formatter.entries[0]
+	if down.Message != "service is not OK now" || down.Data["service"] != "service stub" || down.Data["failures"] != 2 {
+		t.Errorf("Unexpected log entry '%s' %v", down.Message, down.Data)
+	}
+	up := formatter.entries[1]
+	if up.Message != "service is OK now" || up.Data["service"] != "service stub" {
+		t.Errorf("Unexpected log entry '%s' %v", up.Message, up.Data)
+	}
+}
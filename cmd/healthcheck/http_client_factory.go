@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+type HttpClientFactory struct {
+	config    HttpClientConfig
+	transport *http.Transport
+}
+
+func MakeHttpClientFactory(config HttpClientConfig) *HttpClientFactory {
+	return &HttpClientFactory{
+		config:    config,
+		transport: makeTransport(config, config.Timeouts),
+	}
+}
+
+func makeTransport(config HttpClientConfig, timeouts TimeoutsConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   milliseconds(timeouts.Connect),
+		KeepAlive: milliseconds(config.KeepAlive),
+	}).DialContext
+	transport.TLSHandshakeTimeout = milliseconds(timeouts.TlsHandshake)
+	transport.MaxIdleConns = config.MaxIdleConns
+	transport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = milliseconds(config.IdleConnTimeout)
+	// keep-alive is the tcp keep-alive period of the dialer, the reuse of http connections is a separate option
+	transport.DisableKeepAlives = config.DisableKeepAlives
+	return transport
+}
+
+// Timeouts returns the global timeouts with non-zero values of overrides applied on top
+func (factory *HttpClientFactory) Timeouts(overrides TimeoutsConfig) TimeoutsConfig {
+	timeouts := factory.config.Timeouts
+	if overrides.Connect != 0 {
+		timeouts.Connect = overrides.Connect
+	}
+	if overrides.TlsHandshake != 0 {
+		timeouts.TlsHandshake = overrides.TlsHandshake
+	}
+	if overrides.Total != 0 {
+		timeouts.Total = overrides.Total
+	}
+	return timeouts
+}
+
+// MakeClient shares the transport between clients unless the connection timeouts are overridden
+func (factory *HttpClientFactory) MakeClient(overrides TimeoutsConfig) *http.Client {
+	return factory.MakeTlsClient(overrides, nil)
+}
+
+// MakeTlsClient makes a dedicated transport for a custom tls configuration
+func (factory *HttpClientFactory) MakeTlsClient(overrides TimeoutsConfig, tlsConfig *tls.Config) *http.Client {
+	timeouts := factory.Timeouts(overrides)
+	transport := factory.transport
+	if tlsConfig != nil || timeouts.Connect != factory.config.Timeouts.Connect || timeouts.TlsHandshake != factory.config.Timeouts.TlsHandshake {
+		transport = makeTransport(factory.config, timeouts)
+		transport.TLSClientConfig = tlsConfig
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   milliseconds(timeouts.Total),
+	}
+}
+
+// MakeDialer configures the dialer for non-http checks
+func (factory *HttpClientFactory) MakeDialer(overrides TimeoutsConfig) *net.Dialer {
+	return &net.Dialer{Timeout: milliseconds(factory.Timeouts(overrides).Connect)}
+}
+
+func milliseconds(value int) time.Duration {
+	return time.Duration(value) * time.Millisecond
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHttpClientFactory_MakeClient(t *testing.T) {
+	factory := MakeHttpClientFactory(HttpClientConfig{
+		Timeouts:     TimeoutsConfig{Connect: 100, TlsHandshake: 200, Total: 300},
+		MaxIdleConns: 7,
+	})
+	client := factory.MakeClient(TimeoutsConfig{})
+	if client.Timeout != 300*time.Millisecond {
+		t.Errorf("Unexpected total timeout: %s", client.Timeout)
+	}
+	if client.Transport != factory.transport {
+		t.Errorf("Transport should be shared when connection timeouts are not overridden")
+	}
+	if factory.transport.TLSHandshakeTimeout != 200*time.Millisecond {
+		t.Errorf("Unexpected tls handshake timeout: %s", factory.transport.TLSHandshakeTimeout)
+	}
+	if factory.transport.MaxIdleConns != 7 {
+		t.Errorf("Unexpected max idle connections: %d", factory.transport.MaxIdleConns)
+	}
+	overridden := factory.MakeClient(TimeoutsConfig{Total: 1000})
+	if overridden.Timeout != time.Second || overridden.Transport != factory.transport {
+		t.Errorf("Only total timeout should be overridden")
+	}
+	dedicated := factory.MakeClient(TimeoutsConfig{TlsHandshake: 50})
+	if dedicated.Transport == factory.transport {
+		t.Errorf("Transport should not be shared when connection timeouts are overridden")
+	}
+}
+
+func TestHttpClientFactory_MakeDialer(t *testing.T) {
+	factory := MakeHttpClientFactory(HttpClientConfig{Timeouts: TimeoutsConfig{Connect: 100}})
+	if factory.MakeDialer(TimeoutsConfig{}).Timeout != 100*time.Millisecond {
+		t.Errorf("Unexpected connect timeout")
+	}
+	if factory.MakeDialer(TimeoutsConfig{Connect: 20}).Timeout != 20*time.Millisecond {
+		t.Errorf("Unexpected overridden connect timeout")
+	}
+}
+
+func TestHttpClientFactory_DisableKeepAlives(t *testing.T) {
+	if MakeHttpClientFactory(HttpClientConfig{KeepAlive: -1}).transport.DisableKeepAlives {
+		t.Errorf("Negative tcp keep-alive should not disable the connection reuse")
+	}
+	if !MakeHttpClientFactory(HttpClientConfig{DisableKeepAlives: true}).transport.DisableKeepAlives {
+		t.Errorf("Unexpected connection reuse with disable-keep-alives")
+	}
+}
@@ -3,13 +3,43 @@ package main
 import (
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type JavaFormatter struct{}
 
+// Format appends the fields of the entry to the message as sorted key=value pairs
 func (j JavaFormatter) Format(entry *log.Entry) ([]byte, error) {
-	sprintf := fmt.Sprintf("%s [%-7s] %s\n", entry.Time.Format(time.RFC3339), strings.ToUpper(entry.Level.String()), entry.Message)
-	return []byte(sprintf), nil
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("%s [%-7s] %s", entry.Time.Format(time.RFC3339), strings.ToUpper(entry.Level.String()), entry.Message))
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprint(entry.Data[key])
+		if strings.ContainsAny(value, " =\"") {
+			value = strconv.Quote(value)
+		}
+		builder.WriteString(fmt.Sprintf(" %s=%s", key, value))
+	}
+	builder.WriteString("\n")
+	return []byte(builder.String()), nil
+}
+
+func MakeLogFormatter(format string) (log.Formatter, error) {
+	switch format {
+	case "", LogFormatJava:
+		return &JavaFormatter{}, nil
+	case LogFormatJson:
+		return &log.JSONFormatter{TimestampFormat: time.RFC3339Nano}, nil
+	case LogFormatLogfmt:
+		return &log.TextFormatter{DisableColors: true, FullTimestamp: true, TimestampFormat: time.RFC3339Nano}, nil
+	default:
+		return nil, fmt.Errorf("unknown logging format '%s'", format)
+	}
 }
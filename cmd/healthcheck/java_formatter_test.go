@@ -2,6 +2,7 @@ package main
 
 import (
 	log "github.com/sirupsen/logrus"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -20,3 +21,39 @@ func TestJavaFormatter_Format(t *testing.T) {
 		t.Errorf(string(format))
 	}
 }
+
+func TestJavaFormatter_Format_Fields(t *testing.T) {
+	formatter := JavaFormatter{}
+	format, err := formatter.Format(&log.Entry{
+		Time:    time.Time{},
+		Level:   log.WarnLevel,
+		Message: "service is not OK",
+		Data:    log.Fields{"service": "service at 'x'", "failures": 2},
+	})
+	if err != nil {
+		t.Errorf("Unexpected error occured during formatting '%s'", err.Error())
+	}
+	if string(format) != "0001-01-01T00:00:00Z [WARNING] service is not OK failures=2 service=\"service at 'x'\"\n" {
+		t.Errorf(string(format))
+	}
+}
+
+func TestMakeLogFormatter(t *testing.T) {
+	for format, expected := range map[string]log.Formatter{
+		"":              &JavaFormatter{},
+		LogFormatJava:   &JavaFormatter{},
+		LogFormatJson:   &log.JSONFormatter{},
+		LogFormatLogfmt: &log.TextFormatter{},
+	} {
+		formatter, err := MakeLogFormatter(format)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+		if reflect.TypeOf(formatter) != reflect.TypeOf(expected) {
+			t.Errorf("Unexpected formatter %T for format '%s'", formatter, format)
+		}
+	}
+	if _, err := MakeLogFormatter("xml"); err == nil {
+		t.Errorf("Unexpected nil error")
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type logLevelRequest struct {
+	Component string `json:"component"`
+	Level     string `json:"level"`
+	// Duration in milliseconds after which the previous level is restored, zero keeps the level
+	Duration int `json:"duration"`
+}
+
+type revertResponse struct {
+	Level string `json:"level"`
+	At    string `json:"at"`
+}
+
+type logLevelResponse struct {
+	Levels  map[string]string         `json:"levels"`
+	Reverts map[string]revertResponse `json:"reverts,omitempty"`
+}
+
+type pendingRevert struct {
+	timer *time.Timer
+	level logrus.Level
+	at    time.Time
+}
+
+// LogLevelHandler reads and changes the log levels at runtime, it requires a bearer token if one is configured
+type LogLevelHandler struct {
+	loggers *Loggers
+	token   string
+	logger  logrus.FieldLogger
+	mutex   sync.Mutex
+	reverts map[string]*pendingRevert
+}
+
+func MakeLogLevelHandler(loggers *Loggers, token string, logger logrus.FieldLogger) *LogLevelHandler {
+	return &LogLevelHandler{
+		loggers: loggers,
+		token:   token,
+		logger:  logger,
+		reverts: map[string]*pendingRevert{},
+	}
+}
+
+func (lh *LogLevelHandler) authorized(req *http.Request) bool {
+	if lh.token == "" {
+		return true
+	}
+	return hasBearerToken(req, Secret(lh.token))
+}
+
+// change restores the level, which preceded the first of the overlapping temporary changes
+func (lh *LogLevelHandler) change(request logLevelRequest) (int, string) {
+	level, err := logrus.ParseLevel(request.Level)
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	component := request.Component
+	if component == "" {
+		component = ComponentRoot
+	}
+	previous, err := lh.loggers.Level(component)
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	if request.Duration < 0 {
+		return http.StatusBadRequest, "only non-negative durations are valid"
+	}
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+	if revert, ok := lh.reverts[component]; ok {
+		revert.timer.Stop()
+		previous = revert.level
+		delete(lh.reverts, component)
+	}
+	_ = lh.loggers.SetLevel(component, level)
+	lh.logger.Warnf("log level of %s changed to '%s'", component, level)
+	if request.Duration > 0 {
+		revert := &pendingRevert{level: previous, at: time.Now().Add(milliseconds(request.Duration))}
+		revert.timer = time.AfterFunc(milliseconds(request.Duration), func() {
+			lh.mutex.Lock()
+			defer lh.mutex.Unlock()
+			if lh.reverts[component] != revert {
+				return
+			}
+			delete(lh.reverts, component)
+			_ = lh.loggers.SetLevel(component, revert.level)
+			lh.logger.Warnf("log level of %s reverted to '%s'", component, revert.level)
+		})
+		lh.reverts[component] = revert
+	}
+	return http.StatusOK, ""
+}
+
+// Reset forgets the pending reverts, e.g. when the levels are reconfigured
+func (lh *LogLevelHandler) Reset() {
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+	for component, revert := range lh.reverts {
+		revert.timer.Stop()
+		delete(lh.reverts, component)
+	}
+}
+
+func (lh *LogLevelHandler) collect() logLevelResponse {
+	lh.mutex.Lock()
+	defer lh.mutex.Unlock()
+	response := logLevelResponse{Levels: lh.loggers.Levels()}
+	if len(lh.reverts) > 0 {
+		response.Reverts = map[string]revertResponse{}
+		for component, revert := range lh.reverts {
+			response.Reverts[component] = revertResponse{
+				Level: revert.level.String(),
+				At:    revert.at.UTC().Format(time.RFC3339Nano),
+			}
+		}
+	}
+	return response
+}
+
+func (lh *LogLevelHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !lh.authorized(req) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		var request logLevelRequest
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if status, message := lh.change(request); status != http.StatusOK {
+			http.Error(w, message, status)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	response, err := json.Marshal(lh.collect())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(response)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func makeTestLogLevelHandler(token string) (*LogLevelHandler, *Loggers) {
+	root := log.New()
+	loggers := MakeLoggers(root)
+	return MakeLogLevelHandler(loggers, token, silentLogger()), loggers
+}
+
+func serveLogLevel(handler http.Handler, method string, body string, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/admin/log-level", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestLogLevelHandler_ServeHTTP_Get(t *testing.T) {
+	handler, _ := makeTestLogLevelHandler("")
+	rr := serveLogLevel(handler, http.MethodGet, "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+	var response logLevelResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(response.Levels) != len(components)+1 || response.Levels[ComponentRoot] != "info" {
+		t.Errorf("Unexpected levels %v", response.Levels)
+	}
+}
+
+func TestLogLevelHandler_ServeHTTP_Change(t *testing.T) {
+	handler, loggers := makeTestLogLevelHandler("")
+	rr := serveLogLevel(handler, http.MethodPut, `{"component":"scheduler","level":"debug"}`, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+	if level, _ := loggers.Level(ComponentScheduler); level != log.DebugLevel {
+		t.Errorf("Unexpected level '%s'", level)
+	}
+	if level, _ := loggers.Level(ComponentRoot); level != log.InfoLevel {
+		t.Errorf("Unexpected root level '%s'", level)
+	}
+	rr = serveLogLevel(handler, http.MethodPut, `{"level":"warn"}`, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+	if level, _ := loggers.Level(ComponentRoot); level != log.WarnLevel {
+		t.Errorf("Unexpected root level '%s'", level)
+	}
+}
+
+func TestLogLevelHandler_ServeHTTP_Revert(t *testing.T) {
+	handler, loggers := makeTestLogLevelHandler("")
+	serveLogLevel(handler, http.MethodPut, `{"level":"debug","duration":100}`, "")
+	rr := serveLogLevel(handler, http.MethodPut, `{"level":"trace","duration":100}`, "")
+	var response logLevelResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if response.Reverts[ComponentRoot].Level != "info" {
+		t.Errorf("Unexpected reverts %v", response.Reverts)
+	}
+	if level, _ := loggers.Level(ComponentRoot); level != log.TraceLevel {
+		t.Errorf("Unexpected root level '%s'", level)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for level, _ := loggers.Level(ComponentRoot); level != log.InfoLevel; level, _ = loggers.Level(ComponentRoot) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Level has not been reverted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(handler.collect().Reverts) != 0 {
+		t.Errorf("Unexpected pending reverts")
+	}
+}
+
+func TestLogLevelHandler_Reset(t *testing.T) {
+	handler, loggers := makeTestLogLevelHandler("")
+	serveLogLevel(handler, http.MethodPut, `{"level":"debug","duration":50}`, "")
+	handler.Reset()
+	time.Sleep(100 * time.Millisecond)
+	if level, _ := loggers.Level(ComponentRoot); level != log.DebugLevel {
+		t.Errorf("Unexpected root level '%s'", level)
+	}
+}
+
+func TestLogLevelHandler_ServeHTTP_Invalid(t *testing.T) {
+	handler, _ := makeTestLogLevelHandler("")
+	for _, body := range []string{
+		`{"level":"loud"}`,
+		`{"component":"database","level":"debug"}`,
+		`{"level":"debug","duration":-1}`,
+		`level=debug`,
+	} {
+		rr := serveLogLevel(handler, http.MethodPut, body, "")
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Unexpected status code %d for '%s', expected=%d", rr.Code, body, http.StatusBadRequest)
+		}
+	}
+	rr := serveLogLevel(handler, http.MethodDelete, "", "")
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestLogLevelHandler_ServeHTTP_Token(t *testing.T) {
+	handler, _ := makeTestLogLevelHandler("secret")
+	if rr := serveLogLevel(handler, http.MethodGet, "", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status code %d without token, expected=%d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := serveLogLevel(handler, http.MethodGet, "", "wrong"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status code %d with wrong token, expected=%d", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := serveLogLevel(handler, http.MethodGet, "", "secret"); rr.Code != http.StatusOK {
+		t.Errorf("Unexpected status code %d with token, expected=%d", rr.Code, http.StatusOK)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/admin/log-level", nil)
+	req.Header.Set("Authorization", "secret")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Unexpected status code %d with token without scheme, expected=%d", rr.Code, http.StatusUnauthorized)
+	}
+}
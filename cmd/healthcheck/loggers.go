@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ComponentRoot      = "root"
+	ComponentScheduler = "scheduler"
+	ComponentServer    = "server"
+	ComponentChecks    = "checks"
+	ComponentNotifier  = "notifier"
+)
+
+var components = []string{ComponentScheduler, ComponentServer, ComponentChecks, ComponentNotifier}
+
+// Loggers keeps a logger per component, which shares the output and the format of the root logger,
+// but has its own level, the root level applies to the components without a configured level
+type Loggers struct {
+	root       *logrus.Logger
+	components map[string]*logrus.Logger
+}
+
+func MakeLoggers(root *logrus.Logger) *Loggers {
+	loggers := &Loggers{
+		root:       root,
+		components: map[string]*logrus.Logger{},
+	}
+	for _, component := range components {
+		logger := logrus.New()
+		logger.SetOutput(root.Out)
+		logger.SetFormatter(root.Formatter)
+		logger.SetLevel(root.GetLevel())
+		loggers.components[component] = logger
+	}
+	return loggers
+}
+
+func (loggers *Loggers) Root() *logrus.Logger {
+	return loggers.root
+}
+
+func (loggers *Loggers) Component(component string) logrus.FieldLogger {
+	return loggers.components[component].WithField("component", component)
+}
+
+// loggingSettings are validated in advance, so that applying them can not fail
+type loggingSettings struct {
+	formatter logrus.Formatter
+	root      logrus.Level
+	levels    map[string]logrus.Level
+}
+
+func makeLoggingSettings(config LoggingConfig) (loggingSettings, error) {
+	formatter, err := MakeLogFormatter(config.Format)
+	if err != nil {
+		return loggingSettings{}, fmt.Errorf("can not make log formatter: %w", err)
+	}
+	rootLevel, err := logrus.ParseLevel(config.Level.Root)
+	if err != nil {
+		return loggingSettings{}, fmt.Errorf("can not parse log level: %w", err)
+	}
+	levels := map[string]logrus.Level{}
+	for component, level := range config.Level.components() {
+		levels[component] = rootLevel
+		if level == "" {
+			continue
+		}
+		levels[component], err = logrus.ParseLevel(level)
+		if err != nil {
+			return loggingSettings{}, fmt.Errorf("can not parse log level of %s: %w", component, err)
+		}
+	}
+	return loggingSettings{formatter: formatter, root: rootLevel, levels: levels}, nil
+}
+
+// Configure checks the whole configuration before any level or the format is changed
+func (loggers *Loggers) Configure(config LoggingConfig) error {
+	settings, err := makeLoggingSettings(config)
+	if err != nil {
+		return err
+	}
+	loggers.apply(settings)
+	return nil
+}
+
+func (loggers *Loggers) apply(settings loggingSettings) {
+	loggers.root.SetFormatter(settings.formatter)
+	loggers.root.SetLevel(settings.root)
+	for component, logger := range loggers.components {
+		logger.SetFormatter(settings.formatter)
+		logger.SetLevel(settings.levels[component])
+	}
+}
+
+func (loggers *Loggers) logger(component string) (*logrus.Logger, error) {
+	if component == "" || component == ComponentRoot {
+		return loggers.root, nil
+	}
+	logger, ok := loggers.components[component]
+	if !ok {
+		return nil, fmt.Errorf("unknown component '%s'", component)
+	}
+	return logger, nil
+}
+
+func (loggers *Loggers) Level(component string) (logrus.Level, error) {
+	logger, err := loggers.logger(component)
+	if err != nil {
+		return 0, err
+	}
+	return logger.GetLevel(), nil
+}
+
+func (loggers *Loggers) SetLevel(component string, level logrus.Level) error {
+	logger, err := loggers.logger(component)
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+	return nil
+}
+
+// Levels returns the current level of the root logger and of every component
+func (loggers *Loggers) Levels() map[string]string {
+	levels := map[string]string{ComponentRoot: loggers.root.GetLevel().String()}
+	for component, logger := range loggers.components {
+		levels[component] = logger.GetLevel().String()
+	}
+	return levels
+}
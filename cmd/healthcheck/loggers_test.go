@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	log "github.com/sirupsen/logrus"
+	"testing"
+)
+
+func TestLoggers_Configure(t *testing.T) {
+	root := log.New()
+	output := bytes.NewBufferString("")
+	root.SetOutput(output)
+	loggers := MakeLoggers(root)
+	err := loggers.Configure(LoggingConfig{
+		Level:  ConfigLoggingLevel{Root: "info", Scheduler: "debug", Server: "warn"},
+		Format: LogFormatLogfmt,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	expected := map[string]log.Level{
+		ComponentScheduler: log.DebugLevel,
+		ComponentServer:    log.WarnLevel,
+		ComponentChecks:    log.InfoLevel,
+		ComponentNotifier:  log.InfoLevel,
+	}
+	for component, level := range expected {
+		if loggers.components[component].GetLevel() != level {
+			t.Errorf("Unexpected level '%s' of %s, expected='%s'", loggers.components[component].GetLevel(), component, level)
+		}
+		if _, ok := loggers.components[component].Formatter.(*log.TextFormatter); !ok {
+			t.Errorf("Unexpected formatter of %s", component)
+		}
+	}
+	if root.GetLevel() != log.InfoLevel {
+		t.Errorf("Unexpected root level '%s'", root.GetLevel())
+	}
+
+	loggers.Component(ComponentScheduler).Debug("scheduled")
+	loggers.Component(ComponentServer).Info("started")
+	if !bytes.Contains(output.Bytes(), []byte("msg=scheduled component=scheduler")) {
+		t.Errorf("Unexpected output: %s", output.String())
+	}
+	if bytes.Contains(output.Bytes(), []byte("started")) {
+		t.Errorf("Unexpected output: %s", output.String())
+	}
+}
+
+func TestLoggers_Configure_Invalid(t *testing.T) {
+	root := log.New()
+	loggers := MakeLoggers(root)
+	for _, config := range []LoggingConfig{
+		{Level: ConfigLoggingLevel{Root: "loud"}},
+		{Level: ConfigLoggingLevel{Root: "info", Checks: "loud"}},
+		{Level: ConfigLoggingLevel{Root: "debug"}, Format: "xml"},
+	} {
+		if err := loggers.Configure(config); err == nil {
+			t.Errorf("Unexpected nil error for %+v", config)
+		}
+	}
+	if root.GetLevel() != log.InfoLevel {
+		t.Errorf("Unexpected change of the root level '%s'", root.GetLevel())
+	}
+}
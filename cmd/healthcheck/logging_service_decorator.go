@@ -1,28 +1,43 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"time"
 )
 
+// Endpointer is implemented by the services, which know the address they check
+type Endpointer interface {
+	Endpoint() string
+}
+
 type LoggingServiceDecorator struct {
 	backend Service
 	logger  log.FieldLogger
+	fields  log.Fields
 }
 
 func MakeLoggingServiceDecorator(backend Service, logger log.FieldLogger) *LoggingServiceDecorator {
+	fields := log.Fields{"service": backend.Print()}
+	if endpointer, ok := backend.(Endpointer); ok {
+		fields["endpoint"] = endpointer.Endpoint()
+	}
 	return &LoggingServiceDecorator{
 		backend: backend,
 		logger:  logger,
+		fields:  fields,
 	}
 }
 
-func (l *LoggingServiceDecorator) Check() error {
-	err := l.backend.Check()
+func (l *LoggingServiceDecorator) Check(ctx context.Context) error {
+	started := time.Now()
+	err := l.backend.Check(ctx)
+	logger := l.logger.WithFields(l.fields).WithField("latency-ms", float64(time.Since(started))/float64(time.Millisecond))
 	if err == nil {
-		l.logger.Debugf("%s is OK", l.backend.Print())
+		logger.Debug("service is OK")
 	} else {
-		l.logger.Warnf("%s is not OK: %s", l.backend.Print(), err)
+		logger.WithError(err).Warn("service is not OK")
 	}
 	return err
 }
@@ -30,3 +45,10 @@ func (l *LoggingServiceDecorator) Check() error {
 func (l *LoggingServiceDecorator) Print() string {
 	return fmt.Sprintf("logging decorator for %s", l.backend.Print())
 }
+
+func (l *LoggingServiceDecorator) DaysToExpiry() (float64, bool) {
+	if expiring, ok := l.backend.(Expiring); ok {
+		return expiring.DaysToExpiry()
+	}
+	return 0, false
+}
@@ -2,10 +2,12 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	log "github.com/sirupsen/logrus"
-
+	"net"
 	"testing"
+	"time"
 )
 
 func TestMakeLoggingDecorator(t *testing.T) {
@@ -32,7 +34,7 @@ func TestLoggingDecorator_Check_OnSuccess(t *testing.T) {
 	logger.SetOutput(bytes.NewBufferString(""))
 
 	decorator := MakeLoggingServiceDecorator(&ServiceStub{}, logger)
-	err := decorator.Check()
+	err := decorator.Check(context.Background())
 	if err != nil {
 		t.Errorf("Return value should be nil when backend returns nil")
 	}
@@ -40,9 +42,15 @@ func TestLoggingDecorator_Check_OnSuccess(t *testing.T) {
 		t.Errorf("Unexpected number of log entries %d, expected=%d", len(formatter.entries), 1)
 	}
 	entry := formatter.entries[0]
-	if entry.Message != "service stub is OK" {
+	if entry.Message != "service is OK" {
 		t.Errorf("Unexpected message: '%s'", entry.Message)
 	}
+	if entry.Data["service"] != "service stub" {
+		t.Errorf("Unexpected service field: '%v'", entry.Data["service"])
+	}
+	if _, ok := entry.Data["latency-ms"]; !ok {
+		t.Errorf("Latency field is missing")
+	}
 	if entry.Level != log.DebugLevel {
 		t.Errorf("Unexpected log entry level '%s', expected='%s'", entry.Level, log.DebugLevel)
 	}
@@ -56,7 +64,7 @@ func TestLoggingDecorator_Check_OnError(t *testing.T) {
 	logger.SetOutput(bytes.NewBufferString(""))
 
 	decorator := MakeLoggingServiceDecorator(&ServiceStub{Err: errors.New("some error text")}, logger)
-	err := decorator.Check()
+	err := decorator.Check(context.Background())
 	if err == nil {
 		t.Errorf("Return value should be non nil when backend returns non nil")
 	}
@@ -67,9 +75,15 @@ func TestLoggingDecorator_Check_OnError(t *testing.T) {
 		t.Errorf("Unexpected number of log entries %d, expected=%d", len(formatter.entries), 1)
 	}
 	entry := formatter.entries[0]
-	if entry.Message != "service stub is not OK: some error text" {
+	if entry.Message != "service is not OK" {
 		t.Errorf("Unexpected message: '%s'", entry.Message)
 	}
+	if entry.Data["service"] != "service stub" {
+		t.Errorf("Unexpected service field: '%v'", entry.Data["service"])
+	}
+	if entry.Data[log.ErrorKey].(error).Error() != "some error text" {
+		t.Errorf("Unexpected error field: '%v'", entry.Data[log.ErrorKey])
+	}
 	if entry.Level != log.WarnLevel {
 		t.Errorf("Unexpected log entry level '%s', expected='%s'", entry.Level, log.DebugLevel)
 	}
@@ -81,3 +95,20 @@ func TestLoggingDecorator_Print(t *testing.T) {
 		t.Errorf("Unexpected description of the logging decorator")
 	}
 }
+
+func TestLoggingDecorator_Check_Endpoint(t *testing.T) {
+	logger := log.New()
+	logger.SetLevel(log.TraceLevel)
+	formatter := collectingFormatter{entries: []*log.Entry{}}
+	logger.SetFormatter(&formatter)
+	logger.SetOutput(bytes.NewBufferString(""))
+
+	decorator := MakeLoggingServiceDecorator(MakeTcpService("localhost:0", "", &net.Dialer{}, time.Second), logger)
+	_ = decorator.Check(context.Background())
+	if len(formatter.entries) != 1 {
+		t.Fatalf("Unexpected number of log entries %d, expected=%d", len(formatter.entries), 1)
+	}
+	if formatter.entries[0].Data["endpoint"] != "localhost:0" {
+		t.Errorf("Unexpected endpoint field: '%v'", formatter.entries[0].Data["endpoint"])
+	}
+}
@@ -15,25 +15,58 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	app.EnableReload(readConfiguration)
+	if viper.ConfigFileUsed() != "" {
+		if err := watchConfigFile(viper.ConfigFileUsed(), app.Reload, app.logger); err != nil {
+			panic(err)
+		}
+	}
 	app.Run()
 }
 
 func assembleConfiguration() (*Config, error) {
 	viper.SetDefault("server.port", 8080)
+	viper.SetDefault("server.tls.cert-file", "")
+	viper.SetDefault("server.tls.key-file", "")
+	// the credentials are expected to come from SERVER_AUTH_TOKEN, SERVER_AUTH_USERNAME and SERVER_AUTH_PASSWORD
+	viper.SetDefault("server.auth.token", "")
+	viper.SetDefault("server.auth.username", "")
+	viper.SetDefault("server.auth.password", "")
 	viper.SetDefault("logging.level.root", "info")
 	viper.SetDefault("schedule.enabled", "false")
 	viper.SetDefault("pod.namespace", "unknown")
+	viper.SetDefault("success-threshold", 1)
+	viper.SetDefault("shutdown.grace-period", 10000)
+	viper.SetDefault("history.size", defaultHistorySize)
+	viper.SetDefault("notifications.retries", 3)
+	viper.SetDefault("notifications.backoff", 1000)
+	viper.SetDefault("notifications.dedup-window", 60000)
+	viper.SetDefault("admin.enabled", false)
+	viper.SetDefault("admin.address", "")
+	viper.SetDefault("admin.token", "")
+	viper.SetDefault("http-client.timeouts.connect", 2000)
+	viper.SetDefault("http-client.timeouts.tls-handshake", 2000)
+	viper.SetDefault("http-client.timeouts.total", 5000)
+	viper.SetDefault("http-client.keep-alive", 30000)
+	viper.SetDefault("http-client.max-idle-conns", 100)
+	viper.SetDefault("http-client.max-idle-conns-per-host", 2)
+	viper.SetDefault("http-client.idle-conn-timeout", 90000)
+	viper.SetDefault("http-client.disable-keep-alives", false)
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath(".")
 	viper.AddConfigPath("/etc/healthcheck")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
+	return readConfiguration()
+}
+
+func readConfiguration() (*Config, error) {
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return nil, err
 		}
 	}
-	viper.AutomaticEnv()
 	config := Config{}
 	if err := viper.Unmarshal(&config); err != nil {
 		return nil, err
@@ -10,4 +10,7 @@ func TestMain_assembleConfiguration(t *testing.T) {
 	if configuration.Server.Port != 8080 {
 		t.Errorf("Unexpected server.port: %d", configuration.Server.Port)
 	}
+	if configuration.HttpClient.Timeouts.Total != 5000 {
+		t.Errorf("Unexpected http-client.timeouts.total: %d", configuration.HttpClient.Timeouts.Total)
+	}
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var defaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type serviceMetrics struct {
+	service      FragileService
+	identity     serviceIdentity
+	mutex        sync.Mutex
+	checks       uint64
+	failures     uint64
+	bucketCounts []uint64
+	latencySum   float64
+}
+
+func (sm *serviceMetrics) observed() FragileService {
+	return sm.service
+}
+
+func (sm *serviceMetrics) Observe(result CheckResult) {
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.checks++
+	if result.Err != nil {
+		sm.failures++
+	}
+	seconds := result.Latency.Seconds()
+	sm.latencySum += seconds
+	for i, bound := range defaultLatencyBuckets {
+		if seconds <= bound {
+			sm.bucketCounts[i]++
+		}
+	}
+}
+
+// MetricsHandler exposes the state of the services in the Prometheus text format, labelled with their configured names,
+// types and endpoints
+type MetricsHandler struct {
+	aggregate Fragile
+	services  observedServices
+}
+
+func MakeMetricsHandler(aggregate Fragile) *MetricsHandler {
+	return &MetricsHandler{aggregate: aggregate}
+}
+
+func makeServiceMetrics(service FragileService, identity serviceIdentity) *serviceMetrics {
+	return &serviceMetrics{
+		service:      service,
+		identity:     identity,
+		bucketCounts: make([]uint64, len(defaultLatencyBuckets)),
+	}
+}
+
+func (mh *MetricsHandler) Register(service FragileService, identity serviceIdentity) {
+	mh.services.register(makeServiceMetrics(service, identity))
+}
+
+// Update keeps the collected metrics of the services, which are still present
+func (mh *MetricsHandler) Update(services []FragileService, identities map[FragileService]serviceIdentity) {
+	mh.services.update(services, func(service FragileService) serviceObserver {
+		return makeServiceMetrics(service, identities[service])
+	})
+}
+
+func (mh *MetricsHandler) write(buffer *bytes.Buffer) {
+	writeHeader(buffer, "healthcheck_up", "gauge", "Aggregated health status as reported by /health.")
+	_, _ = fmt.Fprintf(buffer, "healthcheck_up %d\n", boolToInt(mh.aggregate.IsOk()))
+
+	observers := mh.services.snapshot()
+	services := make([]*serviceMetrics, len(observers))
+	reports := make([]ServiceReport, len(observers))
+	labels := make([]string, len(observers))
+	for i, observer := range observers {
+		services[i] = observer.(*serviceMetrics)
+		reports[i] = services[i].service.Report()
+		labels[i] = serviceLabels(services[i].identity)
+	}
+
+	writeHeader(buffer, "healthcheck_service_up", "gauge", "Whether the service is considered healthy.")
+	for i, report := range reports {
+		_, _ = fmt.Fprintf(buffer, "healthcheck_service_up{%s} %d\n", labels[i], boolToInt(report.IsOk))
+	}
+	writeHeader(buffer, "healthcheck_service_consecutive_failures", "gauge", "Number of consecutive failed checks.")
+	for i, report := range reports {
+		_, _ = fmt.Fprintf(buffer, "healthcheck_service_consecutive_failures{%s} %d\n", labels[i], report.Failures)
+	}
+	writeHeader(buffer, "healthcheck_service_certificate_days_to_expiry", "gauge", "Days until the earliest certificate of the peer chain expires.")
+	for i, report := range reports {
+		if report.DaysToExpiry != nil {
+			_, _ = fmt.Fprintf(buffer, "healthcheck_service_certificate_days_to_expiry{%s} %s\n",
+				labels[i], strconv.FormatFloat(*report.DaysToExpiry, 'g', -1, 64))
+		}
+	}
+	writeHeader(buffer, "healthcheck_service_checks_total", "counter", "Total number of performed checks.")
+	for i, service := range services {
+		service.mutex.Lock()
+		_, _ = fmt.Fprintf(buffer, "healthcheck_service_checks_total{%s} %d\n", labels[i], service.checks)
+		service.mutex.Unlock()
+	}
+	writeHeader(buffer, "healthcheck_service_failures_total", "counter", "Total number of failed checks.")
+	for i, service := range services {
+		service.mutex.Lock()
+		_, _ = fmt.Fprintf(buffer, "healthcheck_service_failures_total{%s} %d\n", labels[i], service.failures)
+		service.mutex.Unlock()
+	}
+	writeHeader(buffer, "healthcheck_service_check_duration_seconds", "histogram", "Latency of the checks.")
+	for i, service := range services {
+		service.mutex.Lock()
+		for j, bound := range defaultLatencyBuckets {
+			_, _ = fmt.Fprintf(buffer, "healthcheck_service_check_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels[i], strconv.FormatFloat(bound, 'g', -1, 64), service.bucketCounts[j])
+		}
+		_, _ = fmt.Fprintf(buffer, "healthcheck_service_check_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels[i], service.checks)
+		_, _ = fmt.Fprintf(buffer, "healthcheck_service_check_duration_seconds_sum{%s} %s\n", labels[i], strconv.FormatFloat(service.latencySum, 'g', -1, 64))
+		_, _ = fmt.Fprintf(buffer, "healthcheck_service_check_duration_seconds_count{%s} %d\n", labels[i], service.checks)
+		service.mutex.Unlock()
+	}
+}
+
+func (mh *MetricsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	buffer := bytes.Buffer{}
+	mh.write(&buffer)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buffer.Bytes())
+}
+
+func writeHeader(buffer *bytes.Buffer, name string, kind string, help string) {
+	_, _ = fmt.Fprintf(buffer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func serviceLabels(identity serviceIdentity) string {
+	return fmt.Sprintf(`service="%s",type="%s",endpoint="%s"`,
+		escapeLabel(identity.name), escapeLabel(identity.kind), escapeLabel(identity.endpoint))
+}
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsHandler_ServeHTTP(t *testing.T) {
+	service := ServiceStub{}
+	proxy := MakeHopefulProxy(&service, 0, 1, silentLogger())
+	handler := MakeMetricsHandler(&fragileStub{isOk: true})
+	handler.Register(proxy, serviceIdentity{name: "stub", kind: ServiceTypeHttp, endpoint: "http://stub:80/health"})
+	_ = proxy.Check(context.Background())
+	service.Err = errors.New("error")
+	_ = proxy.Check(context.Background())
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodGet})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusOK)
+	}
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected Content-Type '%s'", rr.Header().Get("Content-Type"))
+	}
+	body := rr.Body.String()
+	for _, line := range []string{
+		"# TYPE healthcheck_up gauge",
+		"healthcheck_up 1",
+		`healthcheck_service_up{service="stub",type="http",endpoint="http://stub:80/health"} 0`,
+		`healthcheck_service_consecutive_failures{service="stub",type="http",endpoint="http://stub:80/health"} 1`,
+		`healthcheck_service_checks_total{service="stub",type="http",endpoint="http://stub:80/health"} 2`,
+		`healthcheck_service_failures_total{service="stub",type="http",endpoint="http://stub:80/health"} 1`,
+		"# TYPE healthcheck_service_check_duration_seconds histogram",
+		`healthcheck_service_check_duration_seconds_bucket{service="stub",type="http",endpoint="http://stub:80/health",le="0.005"} 2`,
+		`healthcheck_service_check_duration_seconds_bucket{service="stub",type="http",endpoint="http://stub:80/health",le="+Inf"} 2`,
+		`healthcheck_service_check_duration_seconds_count{service="stub",type="http",endpoint="http://stub:80/health"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Line '%s' is missing in the metrics:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsHandler_ServeHTTP_SharedName(t *testing.T) {
+	config := makeReloadableConfig(
+		ServiceDescription{Type: ServiceTypeTcp, Name: "postgres", Port: 5432},
+		ServiceDescription{Type: ServiceTypeCertificate, Name: "postgres", Port: 5432},
+	)
+	set, err := makeServiceSet(config, MakeHttpClientFactory(HttpClientConfig{}), silentLogger(), &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	handler := MakeMetricsHandler(&fragileStub{isOk: true})
+	for _, service := range set.list {
+		handler.Register(service, set.identities[service])
+	}
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodGet})
+	series := map[string]bool{}
+	for _, line := range strings.Split(strings.TrimSpace(rr.Body.String()), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := strings.Fields(line)[0]
+		if series[name] {
+			t.Errorf("Duplicate series '%s'", name)
+		}
+		series[name] = true
+	}
+	for _, name := range []string{
+		`healthcheck_service_up{service="postgres",type="tcp",endpoint="postgres:5432"}`,
+		`healthcheck_service_up{service="postgres",type="certificate",endpoint="postgres:5432"}`,
+	} {
+		if !series[name] {
+			t.Errorf("Series '%s' is missing in the metrics:\n%s", name, rr.Body.String())
+		}
+	}
+}
+
+func TestMetricsHandler_ServeHTTP_InvalidMethod(t *testing.T) {
+	handler := MakeMetricsHandler(&fragileStub{isOk: true})
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, &http.Request{Method: http.MethodPost})
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Unexpected status code %d in the response, expected=%d", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestMetricsHandler_escapeLabel(t *testing.T) {
+	escaped := escapeLabel("a\"b\\c\nd")
+	if escaped != `a\"b\\c\nd` {
+		t.Errorf("Unexpected escaped label '%s'", escaped)
+	}
+}
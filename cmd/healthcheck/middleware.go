@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// statusRecorder remembers the status of the response for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+func (sr *statusRecorder) Write(body []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(body)
+}
+
+// logRequests logs on debug level, because the probes are requested every few seconds
+func logRequests(handler http.Handler, logger log.FieldLogger) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: rw}
+		handler.ServeHTTP(recorder, req)
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		logger.WithFields(log.Fields{
+			"method":     req.Method,
+			"path":       req.URL.Path,
+			"status":     status,
+			"latency-ms": time.Since(start).Milliseconds(),
+			"remote":     req.RemoteAddr,
+		}).Debug("served request")
+	})
+}
+
+// recoverPanics responds with 500 unless the handler has already started the response
+func recoverPanics(handler http.Handler, logger log.FieldLogger) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: rw}
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			logger.WithFields(log.Fields{
+				"method": req.Method,
+				"path":   req.URL.Path,
+			}).Errorf("handler has panicked: %s\n%s", fmt.Sprint(recovered), debug.Stack())
+			if recorder.status == 0 {
+				http.Error(recorder, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+		}()
+		handler.ServeHTTP(recorder, req)
+	})
+}
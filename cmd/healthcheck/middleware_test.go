@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoverPanics_AbortHandler(t *testing.T) {
+	handler := recoverPanics(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), silentLogger())
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("Unexpected panic %v", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestRecoverPanics_StartedResponse(t *testing.T) {
+	handler := recoverPanics(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("partial"))
+		panic("boom")
+	}), silentLogger())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "partial" {
+		t.Errorf("Unexpected response %d '%s'", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+)
+
+const (
+	NotificationStatusUp   = "up"
+	NotificationStatusDown = "down"
+)
+
+const defaultWebhookTemplate = `{"namespace":{{json .Namespace}},"service":{{json .Service}},"type":{{json .Type}},` +
+	`"endpoint":{{json .Endpoint}},"status":{{json .Status}},"error":{{json .Error}},"time":{{json .Time}}}`
+
+// Notification is the data available in the templates of the webhooks
+type Notification struct {
+	Namespace string
+	Service   string
+	Type      string
+	Endpoint  string
+	Status    string
+	Error     string
+	Time      string
+}
+
+type webhook struct {
+	url      string
+	template *template.Template
+	headers  map[string]string
+}
+
+func makeWebhookTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = defaultWebhookTemplate
+	}
+	return template.New("webhook").Funcs(template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			encoded, err := json.Marshal(value)
+			return string(encoded), err
+		},
+	}).Parse(text)
+}
+
+type transitionObserver struct {
+	notifier *Notifier
+	identity serviceIdentity
+}
+
+func (observer *transitionObserver) Observe(result CheckResult) {
+	if result.WasOk == result.IsOk {
+		return
+	}
+	notification := Notification{
+		Namespace: observer.notifier.namespace,
+		Service:   observer.identity.name,
+		Type:      observer.identity.kind,
+		Endpoint:  observer.identity.endpoint,
+		Status:    NotificationStatusUp,
+		Time:      result.Time.UTC().Format(time.RFC3339Nano),
+	}
+	if !result.IsOk {
+		notification.Status = NotificationStatusDown
+	}
+	if result.Err != nil {
+		notification.Error = result.Err.Error()
+	}
+	observer.notifier.Notify(notification)
+}
+
+// notificationState coalesces the flaps of a service, which happen within the deduplication window after a delivery
+type notificationState struct {
+	status string
+	sentAt time.Time
+	held   *Notification
+	timer  *time.Timer
+}
+
+type delivery struct {
+	hook webhook
+	body []byte
+}
+
+const deliveryQueueSize = 256
+
+// Notifier posts the state transitions of the services to the webhooks in the order they happen,
+// the transitions within the deduplication window after a notification are held and only the latest state is sent
+// once the window ends, so the webhooks always learn the final state of a service,
+// the wait group counts the armed timers, the notifications being sent and the pending deliveries
+type Notifier struct {
+	namespace   string
+	webhooks    []webhook
+	client      *http.Client
+	retries     int
+	backoff     time.Duration
+	dedupWindow time.Duration
+	logger      logrus.FieldLogger
+	mutex       sync.Mutex
+	closing     bool
+	states      map[serviceIdentity]*notificationState
+	registered  map[FragileService]bool
+	queue       chan delivery
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func MakeNotifier(namespace string, config NotificationsConfig, client *http.Client, logger logrus.FieldLogger) (*Notifier, error) {
+	var webhooks []webhook
+	for _, webhookConfig := range config.Webhooks {
+		tmpl, err := makeWebhookTemplate(webhookConfig.Template)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse template of webhook '%s': %w", webhookConfig.Url, err)
+		}
+		webhooks = append(webhooks, webhook{url: webhookConfig.Url, template: tmpl, headers: webhookConfig.Headers})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	notifier := &Notifier{
+		namespace:   namespace,
+		webhooks:    webhooks,
+		client:      client,
+		retries:     config.Retries,
+		backoff:     milliseconds(config.Backoff),
+		dedupWindow: milliseconds(config.DedupWindow),
+		logger:      logger,
+		states:      map[serviceIdentity]*notificationState{},
+		registered:  map[FragileService]bool{},
+		queue:       make(chan delivery, deliveryQueueSize),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	go notifier.work()
+	return notifier, nil
+}
+
+func (nt *Notifier) Register(service FragileService, identity serviceIdentity) {
+	nt.mutex.Lock()
+	defer nt.mutex.Unlock()
+	if nt.registered[service] {
+		return
+	}
+	nt.registered[service] = true
+	service.AddObserver(&transitionObserver{notifier: nt, identity: identity})
+}
+
+// Update forgets the services, which are not present anymore, and the states of their notifications
+// unless a notification is still held
+func (nt *Notifier) Update(services []FragileService, identities map[FragileService]serviceIdentity) {
+	nt.mutex.Lock()
+	present := map[FragileService]bool{}
+	presentIdentities := map[serviceIdentity]bool{}
+	for _, service := range services {
+		present[service] = true
+		presentIdentities[identities[service]] = true
+	}
+	for service := range nt.registered {
+		if !present[service] {
+			delete(nt.registered, service)
+		}
+	}
+	for identity, state := range nt.states {
+		if !presentIdentities[identity] && state.timer == nil {
+			delete(nt.states, identity)
+		}
+	}
+	nt.mutex.Unlock()
+	for _, service := range services {
+		nt.Register(service, identities[service])
+	}
+}
+
+func notificationIdentity(notification Notification) serviceIdentity {
+	return serviceIdentity{name: notification.Service, kind: notification.Type, endpoint: notification.Endpoint}
+}
+
+// Notify sends the notification asynchronously unless it repeats the last sent status or is held within the window
+func (nt *Notifier) Notify(notification Notification) {
+	nt.mutex.Lock()
+	if nt.closing {
+		nt.mutex.Unlock()
+		nt.logger.Warnf("dropped notification about %s, the notifier is stopping", notification.Service)
+		return
+	}
+	now := time.Now()
+	identity := notificationIdentity(notification)
+	state, ok := nt.states[identity]
+	if !ok {
+		state = &notificationState{}
+		nt.states[identity] = state
+	}
+	if state.timer != nil {
+		state.held = &notification
+		nt.mutex.Unlock()
+		nt.logger.Debugf("held notification about %s until the deduplication window ends", notification.Service)
+		return
+	}
+	if state.status == notification.Status {
+		nt.mutex.Unlock()
+		nt.logger.Debugf("suppressed duplicate notification about %s", notification.Service)
+		return
+	}
+	if !state.sentAt.IsZero() && now.Sub(state.sentAt) < nt.dedupWindow {
+		state.held = &notification
+		nt.wg.Add(1)
+		state.timer = time.AfterFunc(state.sentAt.Add(nt.dedupWindow).Sub(now), func() {
+			defer nt.wg.Done()
+			nt.release(identity)
+		})
+		nt.mutex.Unlock()
+		nt.logger.Debugf("held notification about %s until the deduplication window ends", notification.Service)
+		return
+	}
+	state.status = notification.Status
+	state.sentAt = now
+	nt.wg.Add(1)
+	nt.mutex.Unlock()
+	defer nt.wg.Done()
+	nt.send(notification)
+}
+
+// release sends the latest held notification of the service unless the service has returned to the last sent status,
+// the caller accounts for the timer in the wait group
+func (nt *Notifier) release(identity serviceIdentity) {
+	nt.mutex.Lock()
+	state := nt.states[identity]
+	held := state.held
+	state.held = nil
+	state.timer = nil
+	if held == nil || held.Status == state.status {
+		nt.mutex.Unlock()
+		return
+	}
+	state.status = held.Status
+	state.sentAt = time.Now()
+	nt.mutex.Unlock()
+	nt.send(*held)
+}
+
+// releaseHeld refuses further notifications and sends the held ones without waiting for the windows to end,
+// the timers, which can not be stopped anymore, release their notifications on their own
+func (nt *Notifier) releaseHeld() {
+	nt.mutex.Lock()
+	nt.closing = true
+	var identities []serviceIdentity
+	for identity, state := range nt.states {
+		if state.timer != nil && state.timer.Stop() {
+			identities = append(identities, identity)
+		}
+	}
+	nt.mutex.Unlock()
+	for _, identity := range identities {
+		nt.release(identity)
+		nt.wg.Done()
+	}
+}
+
+// send expects the caller to be counted in the wait group, so that the deliveries can be added while AwaitShutdown waits
+func (nt *Notifier) send(notification Notification) {
+	if nt.ctx.Err() != nil {
+		nt.logger.Warnf("dropped notification about %s, the notifier has stopped", notification.Service)
+		return
+	}
+	for _, hook := range nt.webhooks {
+		body := bytes.Buffer{}
+		if err := hook.template.Execute(&body, notification); err != nil {
+			nt.logger.Errorf("can not render notification for webhook '%s': %s", hook.url, err)
+			continue
+		}
+		nt.wg.Add(1)
+		select {
+		case nt.queue <- delivery{hook: hook, body: body.Bytes()}:
+		default:
+			nt.wg.Done()
+			nt.logger.Errorf("can not notify webhook '%s': too many pending notifications", hook.url)
+		}
+	}
+}
+
+func (nt *Notifier) work() {
+	for {
+		select {
+		case <-nt.ctx.Done():
+			return
+		case pending := <-nt.queue:
+			if err := nt.deliver(pending.hook, pending.body); err != nil {
+				nt.logger.Errorf("can not notify webhook '%s': %s", pending.hook.url, err)
+			}
+			nt.wg.Done()
+		}
+	}
+}
+
+// deliver retries with an exponential backoff until the webhook accepts the notification
+func (nt *Notifier) deliver(hook webhook, body []byte) error {
+	backoff := nt.backoff
+	var err error
+	for attempt := 0; attempt <= nt.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-nt.ctx.Done():
+				return fmt.Errorf("gave up after %d attempts: %w", attempt, err)
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = nt.post(hook, body); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("gave up after %d attempts: %w", nt.retries+1, err)
+}
+
+func (nt *Notifier) post(hook webhook, body []byte) error {
+	req, err := http.NewRequestWithContext(nt.ctx, http.MethodPost, hook.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("can not make request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range hook.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := nt.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (nt *Notifier) StartAsync() {}
+
+func (nt *Notifier) Shutdown(context.Context) error {
+	nt.logger.Info("stopping notifier")
+	return nil
+}
+
+// AwaitShutdown abandons the pending notifications if they have not been delivered before ctx is done
+func (nt *Notifier) AwaitShutdown(ctx context.Context) error {
+	nt.releaseHeld()
+	done := make(chan struct{})
+	go func() {
+		nt.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		nt.cancel()
+		nt.logger.Info("notifier stopped")
+		return nil
+	case <-ctx.Done():
+		nt.cancel()
+		return fmt.Errorf("pending notifications have not been delivered in time and were abandoned: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+type webhookRecorder struct {
+	mutex    sync.Mutex
+	failures int
+	attempts int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (recorder *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.attempts++
+	if recorder.attempts <= recorder.failures {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	recorder.bodies = append(recorder.bodies, body)
+	recorder.headers = append(recorder.headers, req.Header)
+}
+
+func (recorder *webhookRecorder) received() [][]byte {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return recorder.bodies
+}
+
+func makeTestNotifier(t *testing.T, config NotificationsConfig) *Notifier {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	notifier, err := MakeNotifier("x-namespace-x", config, &http.Client{}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	return notifier
+}
+
+func awaitNotifications(t *testing.T, notifier *Notifier) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.AwaitShutdown(ctx); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+}
+
+func TestNotifier_Transitions(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{
+		Url:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	}}})
+	service := ServiceStub{}
+	proxy := MakeHopefulProxy(&service, 0, 1, silentLogger())
+	identity := serviceIdentity{name: "stub", kind: ServiceTypeHttp, endpoint: "http://stub:80/health"}
+	notifier.Register(proxy, identity)
+	notifier.Register(proxy, identity)
+	_ = proxy.Check(context.Background())
+	service.Err = errors.New("error")
+	_ = proxy.Check(context.Background())
+	_ = proxy.Check(context.Background())
+	service.Err = nil
+	_ = proxy.Check(context.Background())
+	awaitNotifications(t, notifier)
+
+	bodies := recorder.received()
+	if len(bodies) != 2 {
+		t.Fatalf("Unexpected number of notifications %d", len(bodies))
+	}
+	var down map[string]string
+	if err := json.Unmarshal(bodies[0], &down); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if down["status"] != NotificationStatusDown || down["error"] != "error" || down["namespace"] != "x-namespace-x" ||
+		down["service"] != "stub" || down["type"] != ServiceTypeHttp || down["endpoint"] != "http://stub:80/health" || down["time"] == "" {
+		t.Errorf("Unexpected notification %v", down)
+	}
+	var up map[string]string
+	if err := json.Unmarshal(bodies[1], &up); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if up["status"] != NotificationStatusUp || up["error"] != "" {
+		t.Errorf("Unexpected notification %v", up)
+	}
+	if recorder.headers[0].Get("Authorization") != "Bearer token" || recorder.headers[0].Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected headers %v", recorder.headers[0])
+	}
+}
+
+func TestNotifier_Update(t *testing.T) {
+	notifier := makeTestNotifier(t, NotificationsConfig{})
+	retained := MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger())
+	removed := MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger())
+	notifier.Update([]FragileService{retained, removed},
+		map[FragileService]serviceIdentity{retained: {name: "retained"}, removed: {name: "removed"}})
+	notifier.Notify(Notification{Service: "removed", Status: NotificationStatusDown})
+	notifier.Update([]FragileService{retained}, map[FragileService]serviceIdentity{retained: {name: "retained"}})
+
+	if len(notifier.registered) != 1 || !notifier.registered[retained] {
+		t.Errorf("Unexpected registered services %v", notifier.registered)
+	}
+	if _, ok := notifier.states[serviceIdentity{name: "removed"}]; ok {
+		t.Errorf("Unexpected state of the removed service")
+	}
+	awaitNotifications(t, notifier)
+}
+
+func TestNotifier_Template(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{
+		Url:      server.URL,
+		Template: `{"text": {{json (printf "%s is %s" .Service .Status)}}}`,
+	}}})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	awaitNotifications(t, notifier)
+
+	bodies := recorder.received()
+	if len(bodies) != 1 || string(bodies[0]) != `{"text": "db is down"}` {
+		t.Errorf("Unexpected notifications %q", bodies)
+	}
+}
+
+func TestNotifier_Retry(t *testing.T) {
+	recorder := &webhookRecorder{failures: 2}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{Url: server.URL}}, Retries: 2, Backoff: 10})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	awaitNotifications(t, notifier)
+
+	if len(recorder.received()) != 1 || recorder.attempts != 3 {
+		t.Errorf("Unexpected delivery after %d attempts", recorder.attempts)
+	}
+}
+
+func TestNotifier_RetryExhausted(t *testing.T) {
+	recorder := &webhookRecorder{failures: 5}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{Url: server.URL}}, Retries: 1, Backoff: 10})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	awaitNotifications(t, notifier)
+
+	if len(recorder.received()) != 0 || recorder.attempts != 2 {
+		t.Errorf("Unexpected delivery after %d attempts", recorder.attempts)
+	}
+}
+
+func receivedStatuses(recorder *webhookRecorder) []string {
+	var statuses []string
+	for _, body := range recorder.received() {
+		var notification map[string]string
+		_ = json.Unmarshal(body, &notification)
+		statuses = append(statuses, notification["service"]+" "+notification["status"])
+	}
+	return statuses
+}
+
+func TestNotifier_Deduplication(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{Url: server.URL}}, DedupWindow: 60000})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusUp})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	notifier.Notify(Notification{Service: "cache", Status: NotificationStatusDown})
+	notifier.Notify(Notification{Service: "cache", Status: NotificationStatusUp})
+	awaitNotifications(t, notifier)
+
+	statuses := receivedStatuses(recorder)
+	if len(statuses) != 3 || statuses[0] != "db down" || statuses[1] != "cache down" || statuses[2] != "cache up" {
+		t.Errorf("Unexpected notifications %v", statuses)
+	}
+}
+
+func TestNotifier_Deduplication_LatestStateAfterWindow(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{Url: server.URL}}, DedupWindow: 100})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusUp})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusUp})
+	time.Sleep(300 * time.Millisecond)
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	awaitNotifications(t, notifier)
+
+	statuses := receivedStatuses(recorder)
+	if len(statuses) != 3 || statuses[0] != "db down" || statuses[1] != "db up" || statuses[2] != "db down" {
+		t.Errorf("Unexpected notifications %v", statuses)
+	}
+}
+
+func TestNotifier_AwaitShutdown_Deadline(t *testing.T) {
+	recorder := &webhookRecorder{failures: 5}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{Url: server.URL}}, Retries: 5, Backoff: 10000})
+	notifier.Notify(Notification{Service: "db", Status: NotificationStatusDown})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := notifier.AwaitShutdown(ctx); err == nil {
+		t.Errorf("Unexpected nil error")
+	}
+	notifier.wg.Wait()
+}
+
+func TestNotifier_AwaitShutdown_FiringTimer(t *testing.T) {
+	recorder := &webhookRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+	for i := 0; i < 20; i++ {
+		notifier := makeTestNotifier(t, NotificationsConfig{Webhooks: []WebhookConfig{{Url: server.URL}}, DedupWindow: 1})
+		service := fmt.Sprintf("db-%d", i)
+		notifier.Notify(Notification{Service: service, Status: NotificationStatusDown})
+		notifier.Notify(Notification{Service: service, Status: NotificationStatusUp})
+		time.Sleep(time.Duration(i%3) * 500 * time.Microsecond)
+		awaitNotifications(t, notifier)
+		notifier.Notify(Notification{Service: service, Status: NotificationStatusDown})
+	}
+
+	statuses := receivedStatuses(recorder)
+	if len(statuses) != 40 {
+		t.Fatalf("Unexpected number of notifications %d", len(statuses))
+	}
+	for i := 0; i < 20; i++ {
+		expected := []string{fmt.Sprintf("db-%d down", i), fmt.Sprintf("db-%d up", i)}
+		if statuses[2*i] != expected[0] || statuses[2*i+1] != expected[1] {
+			t.Errorf("Unexpected notifications %v, expected=%v", statuses[2*i:2*i+2], expected)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "sync"
+
+// serviceObserver collects the results of one service
+type serviceObserver interface {
+	CheckObserver
+	observed() FragileService
+}
+
+// observedServices keeps one observer per service, so that the collected results survive a reload, which retains the service
+type observedServices struct {
+	mutex     sync.RWMutex
+	observers []serviceObserver
+}
+
+func (ob *observedServices) register(observer serviceObserver) {
+	observer.observed().AddObserver(observer)
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+	ob.observers = append(ob.observers, observer)
+}
+
+// update keeps the observers of the services, which are still present, and registers the observers, which makeObserver
+// returns for the added services
+func (ob *observedServices) update(services []FragileService, makeObserver func(FragileService) serviceObserver) {
+	ob.mutex.Lock()
+	existing := map[FragileService]serviceObserver{}
+	for _, observer := range ob.observers {
+		existing[observer.observed()] = observer
+	}
+	ob.observers = nil
+	var added []FragileService
+	for _, service := range services {
+		if observer, ok := existing[service]; ok {
+			ob.observers = append(ob.observers, observer)
+		} else {
+			added = append(added, service)
+		}
+	}
+	ob.mutex.Unlock()
+	for _, service := range added {
+		ob.register(makeObserver(service))
+	}
+}
+
+func (ob *observedServices) snapshot() []serviceObserver {
+	ob.mutex.RLock()
+	defer ob.mutex.RUnlock()
+	return append([]serviceObserver{}, ob.observers...)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestObservedServices_update(t *testing.T) {
+	retained := MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger())
+	removed := MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger())
+	added := MakeHopefulProxy(&ServiceStub{}, 0, 1, silentLogger())
+	services := observedServices{}
+	retainedHistory := makeServiceHistory(retained, serviceIdentity{name: "retained"}, 1)
+	services.register(makeServiceHistory(removed, serviceIdentity{name: "removed"}, 1))
+	services.register(retainedHistory)
+
+	made := 0
+	services.update([]FragileService{added, retained}, func(service FragileService) serviceObserver {
+		made++
+		return makeServiceHistory(service, serviceIdentity{name: "added"}, 1)
+	})
+	observers := services.snapshot()
+	if len(observers) != 2 || made != 1 {
+		t.Fatalf("Unexpected observers %d, made %d", len(observers), made)
+	}
+	if observers[0] != retainedHistory || observers[1].observed() != added {
+		t.Errorf("Retained observers should be kept and followed by the added ones")
+	}
+}
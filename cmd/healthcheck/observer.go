@@ -0,0 +1,19 @@
+package main
+
+import "time"
+
+type CheckResult struct {
+	Time    time.Time
+	Latency time.Duration
+	Err     error
+	WasOk   bool
+	IsOk    bool
+}
+
+type CheckObserver interface {
+	Observe(result CheckResult)
+}
+
+type Observable interface {
+	AddObserver(observer CheckObserver)
+}
@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+// checkedOnce is OK as soon as the service has been checked at least once
+type checkedOnce struct {
+	reporter Reporter
+}
+
+func (c *checkedOnce) IsOk() bool {
+	return !c.reporter.Report().LastCheck.IsZero()
+}
+
+// ProbeHandlers serve the Kubernetes probes, only the services tagged accordingly affect liveness and readiness
+type ProbeHandlers struct {
+	Liveness  *HealthHandler
+	Readiness *HealthHandler
+	Startup   *HealthHandler
+}
+
+func MakeProbeHandlers(namespace string, services *serviceSet) (*ProbeHandlers, error) {
+	liveness, err := MakeHealthHandler(namespace, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not make liveness handler: %w", err)
+	}
+	readiness, err := MakeHealthHandler(namespace, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not make readiness handler: %w", err)
+	}
+	startup, err := MakeHealthHandler(namespace, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can not make startup handler: %w", err)
+	}
+	handlers := &ProbeHandlers{
+		Liveness:  liveness,
+		Readiness: readiness,
+		Startup:   startup,
+	}
+	handlers.Update(services)
+	return handlers, nil
+}
+
+func (ph *ProbeHandlers) Update(services *serviceSet) {
+	ph.Liveness.Update(services.aggregate(services.liveness))
+	ph.Readiness.Update(services.aggregate(services.readiness))
+	// the list includes the geo service
+	var started []Fragile
+	for _, service := range services.list {
+		started = append(started, &checkedOnce{reporter: service})
+	}
+	ph.Startup.Update(started, nil, nil)
+}
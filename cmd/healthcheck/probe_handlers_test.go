@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMakeProbeHandlers(t *testing.T) {
+	live := MakeHopefulProxy(&ServiceStub{}, 1, 1, silentLogger())
+	ready := MakeHopefulProxy(&ServiceStub{Err: errors.New("down")}, 0, 1, silentLogger())
+	informational := MakeHopefulProxy(&ServiceStub{Err: errors.New("down")}, 1, 1, silentLogger())
+	handlers, err := MakeProbeHandlers("", &serviceSet{
+		list:      []FragileService{live, ready, informational},
+		liveness:  []FragileService{live},
+		readiness: []FragileService{live, ready},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if handlers.Startup.IsOk() {
+		t.Errorf("Unexpected startup state before any check")
+	}
+	_ = live.Check(context.Background())
+	_ = ready.Check(context.Background())
+	if handlers.Startup.IsOk() {
+		t.Errorf("Unexpected startup state before every service has been checked")
+	}
+	_ = informational.Check(context.Background())
+	if !handlers.Startup.IsOk() {
+		t.Errorf("Unexpected startup state after every service has been checked")
+	}
+	if !handlers.Liveness.IsOk() {
+		t.Errorf("Unexpected liveness state")
+	}
+	if handlers.Readiness.IsOk() {
+		t.Errorf("Unexpected readiness state")
+	}
+}
+
+func TestProbeHandlers_Update_Geo(t *testing.T) {
+	config := makeReloadableConfig(ServiceDescription{Name: "first", Port: 80, Path: "/health"}, ServiceDescription{Name: "second", Port: 80, Path: "/health"})
+	config.Geo = &GeoConfig{Service: "http://geo", Port: 80}
+	services, err := makeServiceSet(config, MakeHttpClientFactory(HttpClientConfig{}), silentLogger(), &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	handlers, err := MakeProbeHandlers("", services)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(handlers.Startup.fragiles) != 3 {
+		t.Errorf("Unexpected number of startup fragiles %d", len(handlers.Startup.fragiles))
+	}
+}
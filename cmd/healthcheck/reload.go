@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/signal"
+	"reflect"
+	"syscall"
+)
+
+// EnableReload makes the application rebuild its services whenever loader provides a new configuration
+func (application *Application) EnableReload(loader func() (*Config, error)) {
+	application.loader = loader
+}
+
+func (application *Application) setUpHangupHandler() {
+	signal.Notify(application.hangups, syscall.SIGHUP)
+	go func() {
+		for sig := range application.hangups {
+			application.logger.Infof("received '%s' signal, reloading configuration", sig)
+			application.Reload()
+		}
+	}()
+}
+
+// Reload applies a new configuration, the previous one keeps running if the new one is rejected,
+// the loader runs under the lock as well, because viper can not be used from several goroutines at once
+func (application *Application) Reload() {
+	application.mutex.Lock()
+	defer application.mutex.Unlock()
+	config, err := application.loader()
+	if err != nil {
+		application.logger.Errorf("rejected new configuration: %s", err)
+		return
+	}
+	if err := application.apply(config); err != nil {
+		application.logger.Errorf("rejected new configuration: %s", err)
+	}
+}
+
+// apply expects the caller to hold the lock
+func (application *Application) apply(config *Config) error {
+	current := application.config
+	if err := config.Verify(); err != nil {
+		return err
+	}
+	if config.Schedule.Enabled != current.Schedule.Enabled {
+		return errors.New("changing schedule.enabled requires restart")
+	}
+	if config.Server != current.Server || config.Pod != current.Pod || config.Shutdown != current.Shutdown || config.History != current.History ||
+		!reflect.DeepEqual(config.Notifications, current.Notifications) || config.Admin != current.Admin {
+		application.logger.Warn("changes of server, pod, shutdown, history, notifications and admin configuration require restart, they are ignored")
+	}
+	logging, err := makeLoggingSettings(config.Logging)
+	if err != nil {
+		return err
+	}
+	if !config.Schedule.Enabled {
+		application.applyLogging(logging)
+		application.config = config
+		return nil
+	}
+	clientFactory := application.clientFactory
+	if config.HttpClient != current.HttpClient {
+		clientFactory = MakeHttpClientFactory(config.HttpClient)
+	}
+	services, err := makeServiceSet(config, clientFactory, application.loggers.Component(ComponentChecks), application.services)
+	if err != nil {
+		return err
+	}
+	if err := application.scheduler.Update(services.checks); err != nil {
+		return fmt.Errorf("can not update scheduler: %w", err)
+	}
+	// nothing fails from here on, so a rejected configuration leaves the log levels and their pending reverts intact
+	application.applyLogging(logging)
+	application.logDiff(application.services, services)
+	application.healthHandler.Update(services.aggregate(services.list))
+	application.probeHandlers.Update(services)
+	application.detailsHandler.Update(toReporters(services.list))
+	application.metricsHandler.Update(services.list, services.identities)
+	application.historyHandler.Update(services.list, services.identities)
+	application.notifier.Update(services.list, services.identities)
+	application.config = config
+	application.clientFactory = clientFactory
+	application.services = services
+	return nil
+}
+
+func (application *Application) applyLogging(logging loggingSettings) {
+	application.loggers.apply(logging)
+	if application.logLevelHandler != nil {
+		application.logLevelHandler.Reset()
+	}
+}
+
+func (application *Application) logDiff(previous *serviceSet, next *serviceSet) {
+	for key, service := range previous.keys {
+		if _, ok := next.keys[key]; !ok {
+			application.logger.Infof("removed check of %s", service.Print())
+		}
+	}
+	for key, service := range next.keys {
+		if _, ok := previous.keys[key]; !ok {
+			application.logger.Infof("added check of %s", service.Print())
+		}
+	}
+	application.logger.Infof("reloaded configuration, %d services are checked", len(next.list))
+}
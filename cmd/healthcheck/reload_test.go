@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func makeReloadableConfig(services ...ServiceDescription) *Config {
+	return &Config{
+		Server:           ServerConfig{Port: 8080},
+		Logging:          LoggingConfig{Level: ConfigLoggingLevel{Root: "info"}},
+		Schedule:         ScheduleConfig{Enabled: true, Delay: 60000},
+		ClientServices:   ClientServicesConfig{Services: services},
+		FailureThreshold: 1,
+	}
+}
+
+func TestApplication_Reload(t *testing.T) {
+	first := ServiceDescription{Name: "first", Port: 80, Path: "/health"}
+	second := ServiceDescription{Name: "second", Port: 80, Path: "/health"}
+	third := ServiceDescription{Type: ServiceTypeTcp, Name: "third", Port: 5432}
+	application, err := MakeApplication(makeReloadableConfig(first, second))
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	retained := application.services.list[0]
+	application.EnableReload(func() (*Config, error) {
+		return makeReloadableConfig(first, third), nil
+	})
+	application.Reload()
+
+	services := application.services.list
+	if len(services) != 2 {
+		t.Fatalf("Unexpected number of services %d", len(services))
+	}
+	if services[0] != retained {
+		t.Errorf("Unchanged service should be retained")
+	}
+	if _, ok := services[1].(*HopefulProxy).backend.(*LoggingServiceDecorator).backend.(*TcpService); !ok {
+		t.Errorf("Added service should be a tcp service")
+	}
+	if len(application.scheduler.jobs) != 2 {
+		t.Errorf("Unexpected number of scheduled jobs %d", len(application.scheduler.jobs))
+	}
+	if _, ok := application.scheduler.jobs[retained]; !ok {
+		t.Errorf("Job of the unchanged service should be retained")
+	}
+	if len(application.healthHandler.fragiles) != 2 || len(application.detailsHandler.reporters) != 2 {
+		t.Errorf("Handlers should be updated")
+	}
+	if metrics := application.metricsHandler.services.snapshot(); len(metrics) != 2 || metrics[0].observed() != retained {
+		t.Errorf("Metrics of the unchanged service should be retained")
+	}
+
+	rescheduled := first
+	rescheduled.Schedule = ServiceScheduleConfig{Interval: 1000}
+	application.EnableReload(func() (*Config, error) {
+		return makeReloadableConfig(rescheduled, third), nil
+	})
+	application.Reload()
+	if application.services.list[0] != retained {
+		t.Errorf("Rescheduled service should be retained")
+	}
+	if application.scheduler.jobs[retained].schedule.Interval != time.Second {
+		t.Errorf("Service should be rescheduled")
+	}
+}
+
+func TestApplication_Reload_Rejected(t *testing.T) {
+	first := ServiceDescription{Name: "first", Port: 80, Path: "/health"}
+	config := makeReloadableConfig(first)
+	application, err := MakeApplication(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	for _, loader := range []func() (*Config, error){
+		func() (*Config, error) { return nil, errors.New("can not read") },
+		func() (*Config, error) { return makeReloadableConfig(ServiceDescription{Type: "udp"}), nil },
+		func() (*Config, error) {
+			invalid := makeReloadableConfig(first)
+			invalid.Schedule.Delay = 0
+			return invalid, nil
+		},
+		func() (*Config, error) {
+			disabled := makeReloadableConfig()
+			disabled.Schedule.Enabled = false
+			return disabled, nil
+		},
+	} {
+		application.EnableReload(loader)
+		application.Reload()
+		if application.config != config {
+			t.Errorf("Rejected configuration should not be applied")
+		}
+		if len(application.services.list) != 1 || len(application.scheduler.jobs) != 1 {
+			t.Errorf("Services should not change after rejection")
+		}
+	}
+}
+
+func TestApplication_Reload_RejectedKeepsLogging(t *testing.T) {
+	config := makeReloadableConfig(ServiceDescription{Name: "first", Port: 80, Path: "/health"})
+	config.Admin = AdminConfig{Enabled: true, Token: "secret"}
+	application, err := MakeApplication(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	defer application.logLevelHandler.Reset()
+	if status, message := application.logLevelHandler.change(logLevelRequest{Component: ComponentChecks, Level: "trace", Duration: 60000}); status != 200 {
+		t.Fatalf("Unexpected status %d: %s", status, message)
+	}
+	application.EnableReload(func() (*Config, error) {
+		rejected := makeReloadableConfig(ServiceDescription{Name: "bad name", Port: 80, Path: "/health"})
+		rejected.Admin = config.Admin
+		rejected.Logging.Level.Root = "debug"
+		return rejected, nil
+	})
+	application.Reload()
+	if application.config != config {
+		t.Fatalf("Rejected configuration should not be applied")
+	}
+	if level := application.loggers.Root().GetLevel(); level != log.InfoLevel {
+		t.Errorf("Unexpected root level '%s' after rejection", level)
+	}
+	if len(application.logLevelHandler.reverts) != 1 {
+		t.Errorf("Pending reverts should be kept after rejection")
+	}
+}
+
+func TestApplication_setUpHangupHandler(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	loaded := make(chan bool, 1)
+	application := Application{
+		logger:  logger,
+		hangups: make(chan os.Signal, 1),
+		loader: func() (*Config, error) {
+			loaded <- true
+			return nil, errors.New("stop here")
+		},
+	}
+	application.setUpHangupHandler()
+	application.hangups <- syscall.SIGHUP
+	select {
+	case <-loaded:
+	case <-time.After(time.Second):
+		t.Errorf("Configuration should be reloaded on SIGHUP")
+	}
+	signal.Stop(application.hangups)
+	close(application.hangups)
+}
+
+func TestApplication_Reload_Serialized(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	var loading int32
+	application := Application{
+		logger: logger,
+		loader: func() (*Config, error) {
+			if atomic.AddInt32(&loading, 1) != 1 {
+				t.Errorf("Configuration should not be loaded concurrently")
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&loading, -1)
+			return nil, errors.New("stop here")
+		},
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			application.Reload()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,18 @@
+package main
+
+import "time"
+
+type ServiceReport struct {
+	Name        string
+	IsOk        bool
+	Failures    int
+	LastError   string
+	LastCheck   time.Time
+	LastLatency time.Duration
+	// DaysToExpiry is known for the services, which check certificates
+	DaysToExpiry *float64
+}
+
+type Reporter interface {
+	Report() ServiceReport
+}
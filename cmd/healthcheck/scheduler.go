@@ -1,7 +1,10 @@
 package main
 
+import "context"
+
 type Lifecycle interface {
 	StartAsync()
-	Shutdown() error
-	AwaitShutdown() error
+	// Shutdown initiates the stop, ctx limits the time the component may spend on it
+	Shutdown(ctx context.Context) error
+	AwaitShutdown(ctx context.Context) error
 }
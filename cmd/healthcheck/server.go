@@ -2,42 +2,90 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 )
 
+// Server owns its routes, so that several servers may run in one process
 type Server struct {
 	server        *http.Server
+	routes        *http.ServeMux
 	errors        chan error
 	logger        log.FieldLogger
 	listenAddress string
+	tls           bool
 }
 
-func MakeServer(port int, handler http.Handler, logger log.FieldLogger) *Server {
-	http.Handle("/health", handler)
-	listenAddress := fmt.Sprintf(":%d", port)
-	server := &http.Server{Addr: listenAddress}
+// MakeServer serves https if the key pair is configured
+func MakeServer(config ServerConfig, logger log.FieldLogger) (*Server, error) {
+	server := MakeServerOnAddress(fmt.Sprintf(":%d", config.Port), logger)
+	if config.Tls.CertFile != "" {
+		reloader, err := MakeCertificateReloader(config.Tls.CertFile, config.Tls.KeyFile, logger)
+		if err != nil {
+			return nil, err
+		}
+		server.server.TLSConfig = &tls.Config{GetCertificate: reloader.GetCertificate}
+		server.tls = true
+	}
+	return server, nil
+}
+
+func MakeServerOnAddress(listenAddress string, logger log.FieldLogger) *Server {
+	routes := http.NewServeMux()
+	server := &http.Server{Addr: listenAddress, Handler: recoverPanics(logRequests(routes, logger), logger)}
 	errors := make(chan error, 1)
-	return &Server{server, errors, logger, listenAddress}
+	return &Server{server, routes, errors, logger, listenAddress, false}
+}
+
+// Handle mounts the handler on the routes of this server only, the patterns follow http.ServeMux
+func (server *Server) Handle(pattern string, handler http.Handler) {
+	server.routes.Handle(pattern, handler)
+}
+
+// ServeHTTP passes the request through the same middleware as the requests, which the server accepts
+func (server *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	server.server.Handler.ServeHTTP(rw, req)
 }
 
 func (server *Server) StartAsync() {
 	server.logger.Info("starting http server")
+	listener, err := net.Listen("tcp", server.listenAddress)
+	if err != nil {
+		server.errors <- err
+		return
+	}
 	go func() {
+		if server.tls {
+			server.logger.Infof("listening on '%s' with tls", server.listenAddress)
+			server.errors <- server.server.ServeTLS(listener, "", "")
+			return
+		}
 		server.logger.Infof("listening on '%s'", server.listenAddress)
-		server.errors <- server.server.ListenAndServe()
+		server.errors <- server.server.Serve(listener)
 	}()
 }
 
-func (server *Server) Shutdown() error {
+func (server *Server) Shutdown(ctx context.Context) error {
 	server.logger.Info("stopping http server")
-	return server.server.Shutdown(context.TODO())
+	err := server.server.Shutdown(ctx)
+	if err != nil {
+		server.logger.Warnf("http server has not drained connections in time: %s", err)
+		return server.server.Close()
+	}
+	return nil
 }
 
-func (server *Server) AwaitShutdown() error {
+func (server *Server) AwaitShutdown(ctx context.Context) error {
 	server.logger.Info("waiting for http server to stop")
-	result := <-server.errors
+	var result error
+	select {
+	case result = <-server.errors:
+	case <-ctx.Done():
+		return fmt.Errorf("http server has not stopped in time: %w", ctx.Err())
+	}
 	server.logger.Info("http server stopped")
 	if result == nil || result == http.ErrServerClosed {
 		return nil
@@ -2,23 +2,30 @@ package main
 
 import (
 	"bytes"
+	"context"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestServer_Lifecycle(t *testing.T) {
 	logger := log.New()
 	logger.SetOutput(bytes.NewBufferString(""))
-	server := MakeServer(8080, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	server, err := MakeServer(ServerConfig{Port: 8080}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	server.Handle("/health", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		rw.WriteHeader(http.StatusOK)
 		_, err := rw.Write([]byte("hello"))
 		if err != nil {
 			t.Errorf("Unexpected error on response writing: %s", err)
 		}
-	}), logger)
+	}))
 	server.StartAsync()
 	client := http.Client{}
 	resp, err := client.Get("http://localhost:8080/health")
@@ -37,13 +44,98 @@ func TestServer_Lifecycle(t *testing.T) {
 	if s != "hello" {
 		t.Errorf("Unexpected body '%s', expected='%s'", s, "hello")
 	}
-	http.DefaultServeMux = new(http.ServeMux)
-	err = server.Shutdown()
+	err = server.Shutdown(context.Background())
 	if err != nil {
 		t.Errorf("Unexpected error on server shutdown: %s", err)
 	}
-	err = server.AwaitShutdown()
+	err = server.AwaitShutdown(context.Background())
 	if err != nil {
 		t.Errorf("Unexpected error on server shutdown awaiting: %s", err)
 	}
 }
+
+func TestServer_Tls(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	certFile, keyFile := makeSelfSignedCertificate(t, t.TempDir(), "localhost", time.Now().Add(time.Hour))
+	server, err := MakeServer(ServerConfig{Port: 8443, Tls: ServerTlsConfig{CertFile: certFile, KeyFile: keyFile}}, logger)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	server.Handle("/health", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	server.StartAsync()
+	defer func() {
+		_ = server.Shutdown(context.Background())
+		_ = server.AwaitShutdown(context.Background())
+	}()
+	err = checkTls(t, "https://localhost:8443/health", TlsConfig{CaFile: certFile})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	err = checkTls(t, "http://localhost:8443/health", TlsConfig{})
+	if err == nil {
+		t.Errorf("Unexpected nil error for plain http")
+	}
+}
+
+func TestServer_SeparateRoutes(t *testing.T) {
+	first := MakeServerOnAddress(":8080", silentLogger())
+	second := MakeServerOnAddress(":8081", silentLogger())
+	handler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusNoContent)
+	})
+	first.Handle("/health", handler)
+	second.Handle("/health", handler)
+	second.Handle("/metrics", handler)
+	for _, testCase := range []struct {
+		server   *Server
+		path     string
+		expected int
+	}{
+		{first, "/health", http.StatusNoContent},
+		{first, "/metrics", http.StatusNotFound},
+		{second, "/metrics", http.StatusNoContent},
+	} {
+		rr := httptest.NewRecorder()
+		testCase.server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+		if rr.Code != testCase.expected {
+			t.Errorf("Unexpected status %d of '%s', expected=%d", rr.Code, testCase.path, testCase.expected)
+		}
+	}
+}
+
+func TestServer_RecoversPanics(t *testing.T) {
+	logger := log.New()
+	formatter := collectingFormatter{entries: []*log.Entry{}}
+	logger.SetFormatter(&formatter)
+	logger.SetOutput(bytes.NewBufferString(""))
+	logger.SetLevel(log.DebugLevel)
+	server := MakeServerOnAddress(":8080", logger)
+	server.Handle("/panic", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		panic("boom")
+	}))
+	server.Handle("/health", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusTeapot)
+	}))
+
+	rr := httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Unexpected status %d, expected=%d", rr.Code, http.StatusInternalServerError)
+	}
+	if len(formatter.entries) != 1 || formatter.entries[0].Level != log.ErrorLevel || formatter.entries[0].Data["path"] != "/panic" {
+		t.Fatalf("Unexpected log entries %v", formatter.entries)
+	}
+
+	rr = httptest.NewRecorder()
+	server.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if len(formatter.entries) != 2 {
+		t.Fatalf("Unexpected number of log entries %d, expected=%d", len(formatter.entries), 2)
+	}
+	entry := formatter.entries[1]
+	if entry.Message != "served request" || entry.Data["status"] != http.StatusTeapot || entry.Data["path"] != "/health" {
+		t.Errorf("Unexpected log entry '%s' %v", entry.Message, entry.Data)
+	}
+}
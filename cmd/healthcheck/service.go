@@ -1,6 +1,41 @@
 package main
 
+import "context"
+
+// Service checks a dependency, the check is cancelled when ctx is done
 type Service interface {
+	Check(ctx context.Context) error
+	Print() string
+}
+
+// LegacyService is a check, which is not aware of cancellation
+type LegacyService interface {
 	Check() error
 	Print() string
 }
+
+type legacyServiceAdapter struct {
+	backend LegacyService
+}
+
+// AdaptLegacyService makes a Service, which stops waiting for the legacy check when ctx is done
+func AdaptLegacyService(backend LegacyService) Service {
+	return &legacyServiceAdapter{backend: backend}
+}
+
+func (adapter *legacyServiceAdapter) Check(ctx context.Context) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- adapter.backend.Check()
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (adapter *legacyServiceAdapter) Print() string {
+	return adapter.backend.Print()
+}
@@ -0,0 +1,39 @@
+package main
+
+import "fmt"
+
+// ServiceGroup is OK when enough of its members are OK according to the policy of the group
+type ServiceGroup struct {
+	description GroupDescription
+	members     []Fragile
+}
+
+func MakeServiceGroup(description GroupDescription, members []Fragile) *ServiceGroup {
+	return &ServiceGroup{
+		description: description,
+		members:     members,
+	}
+}
+
+func (group *ServiceGroup) IsOk() bool {
+	ok := 0
+	for _, member := range group.members {
+		if member.IsOk() {
+			ok++
+		}
+	}
+	switch group.description.Policy {
+	case GroupPolicyAny:
+		return ok > 0
+	case GroupPolicyAtLeast:
+		return ok >= group.description.Min
+	case GroupPolicyPercentage:
+		return float64(ok)*100 >= group.description.Percentage*float64(len(group.members))
+	default:
+		return ok == len(group.members)
+	}
+}
+
+func (group *ServiceGroup) Report() ServiceReport {
+	return ServiceReport{Name: fmt.Sprintf("group '%s'", group.description.Name), IsOk: group.IsOk()}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestServiceGroup_IsOk(t *testing.T) {
+	ok := &fragileStub{isOk: true}
+	notOk := &fragileStub{isOk: false}
+	members := []Fragile{ok, notOk, notOk}
+	for _, testCase := range []struct {
+		description GroupDescription
+		members     []Fragile
+		expected    bool
+	}{
+		{GroupDescription{}, members, false},
+		{GroupDescription{Policy: GroupPolicyAll}, []Fragile{ok, ok}, true},
+		{GroupDescription{Policy: GroupPolicyAny}, members, true},
+		{GroupDescription{Policy: GroupPolicyAny}, []Fragile{notOk}, false},
+		{GroupDescription{Policy: GroupPolicyAtLeast, Min: 1}, members, true},
+		{GroupDescription{Policy: GroupPolicyAtLeast, Min: 2}, members, false},
+		{GroupDescription{Policy: GroupPolicyPercentage, Percentage: 50}, []Fragile{ok, notOk}, true},
+		{GroupDescription{Policy: GroupPolicyPercentage, Percentage: 50}, members, false},
+	} {
+		group := MakeServiceGroup(testCase.description, testCase.members)
+		if group.IsOk() != testCase.expected {
+			t.Errorf("Unexpected state of group %+v, expected=%t", testCase.description, testCase.expected)
+		}
+	}
+}
+
+func TestServiceGroup_Report(t *testing.T) {
+	group := MakeServiceGroup(GroupDescription{Name: "databases"}, []Fragile{&fragileStub{isOk: false}})
+	report := group.Report()
+	if report.Name != "group 'databases'" || report.IsOk {
+		t.Errorf("Unexpected report %+v", report)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+)
+
+// serviceSet keeps the checked services in the configuration order, keyed by their configuration
+type serviceSet struct {
+	list       []FragileService
+	checks     []ScheduledCheck
+	keys       map[string]FragileService
+	geo        FragileService
+	liveness   []FragileService
+	readiness  []FragileService
+	optional   map[FragileService]bool
+	groups     []GroupDescription
+	memberOf   map[FragileService]string
+	identities map[FragileService]serviceIdentity
+	rules      []RuleDescription
+}
+
+// serviceIdentity tells the checks apart in the metrics, the history and the notifications,
+// the configured name alone is shared by all the checks of a host
+type serviceIdentity struct {
+	name     string
+	kind     string
+	endpoint string
+}
+
+func makeSchedule(config *Config, overrides ServiceScheduleConfig, timeout int) Schedule {
+	interval := config.Schedule.Delay
+	if overrides.Interval > 0 {
+		interval = overrides.Interval
+	}
+	return Schedule{
+		Interval:     milliseconds(interval),
+		InitialDelay: milliseconds(overrides.InitialDelay),
+		Jitter:       milliseconds(overrides.Jitter),
+		Cron:         overrides.Cron,
+		Timeout:      milliseconds(timeout),
+	}
+}
+
+func serviceKey(config *Config, description interface{}, occurrence int) string {
+	key, err := json.Marshal(struct {
+		Description      interface{}
+		FailureThreshold int
+		SuccessThreshold int
+		HttpClient       HttpClientConfig
+		Occurrence       int
+	}{description, config.FailureThreshold, config.SuccessThreshold, config.HttpClient, occurrence})
+	if err != nil {
+		return fmt.Sprintf("%+v", description)
+	}
+	return string(key)
+}
+
+// makeServiceSet reuses services of the previous set, which have not changed, so that their state is preserved
+func makeServiceSet(config *Config, clientFactory *HttpClientFactory, logger logrus.FieldLogger, previous *serviceSet) (*serviceSet, error) {
+	result := &serviceSet{
+		keys:       map[string]FragileService{},
+		optional:   map[FragileService]bool{},
+		groups:     config.ClientServices.Groups,
+		memberOf:   map[FragileService]string{},
+		identities: map[FragileService]serviceIdentity{},
+		rules:      config.ClientServices.Rules,
+	}
+	occurrences := map[string]int{}
+	identities := map[serviceIdentity]int{}
+	// the checks of an endpoint, which differ only in the request, are numbered by their occurrence
+	identify := func(identity serviceIdentity) serviceIdentity {
+		identities[identity]++
+		if occurrence := identities[identity]; occurrence > 1 {
+			identity.endpoint = fmt.Sprintf("%s#%d", identity.endpoint, occurrence)
+		}
+		return identity
+	}
+	for _, srvDesc := range config.ClientServices.Services {
+		// schedule and probe changes do not affect the check, the state of the service is kept
+		keyDesc := srvDesc
+		keyDesc.Schedule = ServiceScheduleConfig{}
+		keyDesc.Affects = ""
+		keyDesc.Criticality = ""
+		keyDesc.Group = ""
+		baseKey := serviceKey(config, keyDesc, 0)
+		key := serviceKey(config, keyDesc, occurrences[baseKey])
+		occurrences[baseKey]++
+		service, ok := previous.keys[key]
+		if !ok {
+			services, err := makeServiceList(config.FailureThreshold, config.SuccessThreshold, []ServiceDescription{srvDesc}, clientFactory, logger)
+			if err != nil {
+				return nil, fmt.Errorf("can not make service list: %w", err)
+			}
+			service = services[0]
+		}
+		result.keys[key] = service
+		result.list = append(result.list, service)
+		result.checks = append(result.checks, ScheduledCheck{Service: service, Schedule: makeSchedule(config, srvDesc.Schedule, clientFactory.Timeouts(srvDesc.Timeouts).Total)})
+		if srvDesc.Criticality == CriticalityNonCritical {
+			result.optional[service] = true
+		}
+		if srvDesc.Group != "" {
+			result.memberOf[service] = srvDesc.Group
+		}
+		kind := srvDesc.Type
+		if kind == "" {
+			kind = ServiceTypeHttp
+		}
+		result.identities[service] = identify(serviceIdentity{name: srvDesc.Name, kind: kind, endpoint: serviceEndpoint(srvDesc)})
+		switch srvDesc.Affects {
+		case "", AffectsReadiness:
+			result.readiness = append(result.readiness, service)
+		case AffectsLiveness:
+			result.liveness = append(result.liveness, service)
+			result.readiness = append(result.readiness, service)
+		}
+	}
+	if config.Geo != nil {
+		keyDesc := *config.Geo
+		keyDesc.Schedule = ServiceScheduleConfig{}
+		key := serviceKey(config, keyDesc, 0)
+		service, ok := previous.keys[key]
+		if !ok {
+			var err error
+			service, err = makeGeoService(config, clientFactory, logger)
+			if err != nil {
+				return nil, fmt.Errorf("can not make geo-service: %w", err)
+			}
+		}
+		result.keys[key] = service
+		result.list = append(result.list, service)
+		result.checks = append(result.checks, ScheduledCheck{Service: service, Schedule: makeSchedule(config, config.Geo.Schedule, clientFactory.Timeouts(config.Geo.Timeouts).Total)})
+		result.geo = service
+		result.identities[service] = identify(serviceIdentity{name: RuleGeo, kind: ServiceTypeHttp, endpoint: geoEndpoint(config.Geo)})
+		result.readiness = append(result.readiness, service)
+		// the status is healthy regardless of the other services, when the geo-healthcheck is down
+		geoRule := RuleDescription{When: RuleGeo, Is: RuleStateDown, Then: RuleEffectIgnore, Targets: []string{RuleTargetAll}}
+		result.rules = append([]RuleDescription{geoRule}, result.rules...)
+	}
+	return result, nil
+}
+
+// aggregate replaces the grouped services by their groups, separates the critical fragiles from the optional ones
+// and resolves the rules, a group consists only of its members among the given services and its own criticality applies
+func (set *serviceSet) aggregate(services []FragileService) ([]Fragile, []Fragile, []DependencyRule) {
+	var critical, optional []Fragile
+	targets := map[string][]Fragile{}
+	members := map[string][]Fragile{}
+	for _, service := range services {
+		if group, ok := set.memberOf[service]; ok {
+			members[group] = append(members[group], service)
+			continue
+		}
+		if set.optional[service] {
+			optional = append(optional, service)
+		} else {
+			critical = append(critical, service)
+		}
+		name := set.identities[service].name
+		targets[name] = append(targets[name], service)
+	}
+	for _, group := range set.groups {
+		if _, ok := members[group.Name]; !ok {
+			continue
+		}
+		serviceGroup := MakeServiceGroup(group, members[group.Name])
+		if group.Criticality == CriticalityNonCritical {
+			optional = append(optional, serviceGroup)
+		} else {
+			critical = append(critical, serviceGroup)
+		}
+		targets[group.Name] = append(targets[group.Name], serviceGroup)
+	}
+	var rules []DependencyRule
+	for _, ruleDesc := range set.rules {
+		condition := set.condition(ruleDesc.When)
+		if condition == nil {
+			continue
+		}
+		rule := DependencyRule{Condition: condition, WhenOk: ruleDesc.Is == RuleStateUp, Effect: ruleDesc.Then}
+		for _, target := range ruleDesc.Targets {
+			if target == RuleTargetAll {
+				rule.All = true
+			}
+			rule.Targets = append(rule.Targets, targets[target]...)
+		}
+		if rule.All || len(rule.Targets) > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return critical, optional, rules
+}
+
+// condition finds the group or the service by its name, the groups consist of all their members
+func (set *serviceSet) condition(name string) Fragile {
+	for _, group := range set.groups {
+		if group.Name != name {
+			continue
+		}
+		var members []Fragile
+		for _, service := range set.list {
+			if set.memberOf[service] == name {
+				members = append(members, service)
+			}
+		}
+		return MakeServiceGroup(group, members)
+	}
+	for _, service := range set.list {
+		if set.identities[service].name == name {
+			return service
+		}
+	}
+	return nil
+}
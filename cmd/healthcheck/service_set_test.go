@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	log "github.com/sirupsen/logrus"
+	"testing"
+)
+
+func TestApplication_makeServiceSet_probes(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	config := makeReloadableConfig(
+		ServiceDescription{Name: "default", Port: 80},
+		ServiceDescription{Name: "liveness", Port: 80, Affects: AffectsLiveness},
+		ServiceDescription{Name: "readiness", Port: 80, Affects: AffectsReadiness},
+		ServiceDescription{Name: "none", Port: 80, Affects: AffectsNone},
+	)
+	config.Geo = &GeoConfig{Service: "http://geo", Port: 80}
+	set, err := makeServiceSet(config, MakeHttpClientFactory(HttpClientConfig{}), logger, &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if len(set.liveness) != 1 || set.liveness[0] != set.list[1] {
+		t.Errorf("Unexpected liveness services %v", set.liveness)
+	}
+	expected := []FragileService{set.list[0], set.list[1], set.list[2], set.geo}
+	if len(set.readiness) != len(expected) {
+		t.Fatalf("Unexpected number of readiness services %d", len(set.readiness))
+	}
+	for i, service := range set.readiness {
+		if service != expected[i] {
+			t.Errorf("Unexpected readiness service '%s' at %d", service.Print(), i)
+		}
+	}
+}
+
+func TestApplication_makeServiceSet_criticality(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	config := makeReloadableConfig(
+		ServiceDescription{Name: "default", Port: 80},
+		ServiceDescription{Name: "critical", Port: 80, Criticality: CriticalityCritical},
+		ServiceDescription{Name: "optional", Port: 80, Criticality: CriticalityNonCritical},
+	)
+	set, err := makeServiceSet(config, MakeHttpClientFactory(HttpClientConfig{}), logger, &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	critical, optional, _ := set.aggregate(set.list)
+	if len(critical) != 2 || critical[0] != set.list[0] || critical[1] != set.list[1] {
+		t.Errorf("Unexpected critical services %v", critical)
+	}
+	if len(optional) != 1 || optional[0] != set.list[2] {
+		t.Errorf("Unexpected optional services %v", optional)
+	}
+}
+
+func TestApplication_makeServiceSet_groups(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	config := makeReloadableConfig(
+		ServiceDescription{Name: "standalone", Port: 80},
+		ServiceDescription{Name: "cache-1", Port: 80, Group: "cache"},
+		ServiceDescription{Name: "cache-2", Port: 80, Group: "cache", Affects: AffectsLiveness},
+		ServiceDescription{Name: "auth-1", Port: 80, Group: "auth"},
+	)
+	config.ClientServices.Groups = []GroupDescription{
+		{Name: "cache", Policy: GroupPolicyAny, Criticality: CriticalityNonCritical},
+		{Name: "auth", Policy: GroupPolicyAll},
+		{Name: "unused"},
+	}
+	set, err := makeServiceSet(config, MakeHttpClientFactory(HttpClientConfig{}), logger, &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	critical, optional, _ := set.aggregate(set.list)
+	if len(critical) != 2 || critical[0] != set.list[0] {
+		t.Fatalf("Unexpected critical fragiles %v", critical)
+	}
+	if auth, ok := critical[1].(*ServiceGroup); !ok || auth.description.Name != "auth" || len(auth.members) != 1 {
+		t.Errorf("Unexpected critical group %v", critical[1])
+	}
+	if len(optional) != 1 {
+		t.Fatalf("Unexpected optional fragiles %v", optional)
+	}
+	if cache, ok := optional[0].(*ServiceGroup); !ok || cache.description.Name != "cache" || len(cache.members) != 2 {
+		t.Errorf("Unexpected optional group %v", optional[0])
+	}
+	critical, optional, _ = set.aggregate(set.liveness)
+	if len(critical) != 0 || len(optional) != 1 || len(optional[0].(*ServiceGroup).members) != 1 {
+		t.Errorf("Unexpected liveness fragiles %v %v", critical, optional)
+	}
+}
+
+func TestApplication_makeServiceSet_rules(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(bytes.NewBufferString(""))
+	config := makeReloadableConfig(
+		ServiceDescription{Name: "primary", Port: 80},
+		ServiceDescription{Name: "secondary", Port: 80},
+		ServiceDescription{Name: "cache-1", Port: 80, Group: "cache"},
+	)
+	config.ClientServices.Groups = []GroupDescription{{Name: "cache"}}
+	config.ClientServices.Rules = []RuleDescription{
+		{When: "primary", Is: RuleStateUp, Then: RuleEffectIgnore, Targets: []string{"secondary"}},
+		{When: "cache", Is: RuleStateDown, Then: RuleEffectFail, Targets: []string{"primary", "cache"}},
+	}
+	config.Geo = &GeoConfig{Service: "http://geo", Port: 80}
+	set, err := makeServiceSet(config, MakeHttpClientFactory(HttpClientConfig{}), logger, &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	critical, _, rules := set.aggregate(set.list)
+	if len(rules) != 3 {
+		t.Fatalf("Unexpected number of rules %d", len(rules))
+	}
+	if rules[0].Condition != set.geo || rules[0].WhenOk || !rules[0].All || rules[0].Effect != RuleEffectIgnore {
+		t.Errorf("Unexpected geo rule %+v", rules[0])
+	}
+	if rules[1].Condition != set.list[0] || !rules[1].WhenOk || len(rules[1].Targets) != 1 || rules[1].Targets[0] != set.list[1] {
+		t.Errorf("Unexpected rule %+v", rules[1])
+	}
+	if _, ok := rules[2].Condition.(*ServiceGroup); !ok || rules[2].WhenOk || len(rules[2].Targets) != 2 {
+		t.Fatalf("Unexpected rule %+v", rules[2])
+	}
+	if rules[2].Targets[0] != set.list[0] || rules[2].Targets[1] != critical[len(critical)-1] {
+		t.Errorf("Unexpected targets %v of the group rule", rules[2].Targets)
+	}
+	_, _, rules = set.aggregate(set.liveness)
+	if len(rules) != 1 || !rules[0].All {
+		t.Errorf("Unexpected liveness rules %+v", rules)
+	}
+}
+
+func TestApplication_makeServiceSet_identities(t *testing.T) {
+	config := makeReloadableConfig(
+		ServiceDescription{Type: ServiceTypeTcp, Name: "postgres", Port: 5432},
+		ServiceDescription{Type: ServiceTypeCertificate, Name: "postgres", Port: 5432},
+		ServiceDescription{Name: "api", Port: 80, Path: "/health"},
+		ServiceDescription{Name: "api", Port: 80, Path: "/health", Method: "HEAD"},
+	)
+	set, err := makeServiceSet(config, MakeHttpClientFactory(HttpClientConfig{}), silentLogger(), &serviceSet{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	expected := []serviceIdentity{
+		{name: "postgres", kind: ServiceTypeTcp, endpoint: "postgres:5432"},
+		{name: "postgres", kind: ServiceTypeCertificate, endpoint: "postgres:5432"},
+		{name: "api", kind: ServiceTypeHttp, endpoint: "http://api:80/health"},
+		{name: "api", kind: ServiceTypeHttp, endpoint: "http://api:80/health#2"},
+	}
+	for i, service := range set.list {
+		if set.identities[service] != expected[i] {
+			t.Errorf("Unexpected identity %+v at %d, expected=%+v", set.identities[service], i, expected[i])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type legacyServiceStub struct {
+	err      error
+	duration time.Duration
+}
+
+func (s *legacyServiceStub) Check() error {
+	time.Sleep(s.duration)
+	return s.err
+}
+
+func (s *legacyServiceStub) Print() string {
+	return "legacy service stub"
+}
+
+func TestAdaptLegacyService(t *testing.T) {
+	service := AdaptLegacyService(&legacyServiceStub{err: errors.New("error")})
+	err := service.Check(context.Background())
+	if err == nil || err.Error() != "error" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if service.Print() != "legacy service stub" {
+		t.Errorf("Unexpected description '%s'", service.Print())
+	}
+}
+
+func TestAdaptLegacyService_Cancelled(t *testing.T) {
+	service := AdaptLegacyService(&legacyServiceStub{duration: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err := service.Check(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if time.Since(started) > 500*time.Millisecond {
+		t.Errorf("Check should not wait for the legacy service after cancellation")
+	}
+}
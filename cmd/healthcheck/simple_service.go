@@ -1,42 +1,88 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 )
 
+const defaultMaxBodySize = 1 << 20
+
+type SimpleServiceOptions struct {
+	Method      string
+	Headers     map[string]string
+	Body        string
+	Statuses    StatusRanges
+	Assertions  []BodyAssertion
+	MaxBodySize int64
+}
+
 type SimpleService struct {
 	endpoint string
 	client   *http.Client
 	name     string
-	request  *http.Request
+	options  SimpleServiceOptions
 }
 
 func MakeSimpleService(endpoint string, client *http.Client) (Service, error) {
-	request, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("can not make request object: %w", err)
+	return MakeSimpleServiceWithOptions(endpoint, client, SimpleServiceOptions{})
+}
+
+func MakeSimpleServiceWithOptions(endpoint string, client *http.Client, options SimpleServiceOptions) (Service, error) {
+	if options.Method == "" {
+		options.Method = http.MethodGet
 	}
-	request.Header.Set("Accept", "application/json")
-	return &SimpleService{
+	if options.Statuses == nil {
+		options.Statuses = defaultStatusRanges
+	}
+	srv := &SimpleService{
 		endpoint: endpoint,
 		client:   client,
 		name:     fmt.Sprintf("service at '%s'", endpoint),
-		request:  request,
-	}, nil
+		options:  options,
+	}
+	if _, err := srv.newRequest(context.Background()); err != nil {
+		return nil, err
+	}
+	return srv, nil
+}
+
+func (srv *SimpleService) newRequest(ctx context.Context) (*http.Request, error) {
+	var body io.Reader
+	if srv.options.Body != "" {
+		body = strings.NewReader(srv.options.Body)
+	}
+	request, err := http.NewRequestWithContext(ctx, srv.options.Method, srv.endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("can not make request object: %w", err)
+	}
+	request.Header.Set("Accept", "application/json")
+	for name, value := range srv.options.Headers {
+		request.Header.Set(name, value)
+	}
+	return request, nil
 }
 
 func (srv *SimpleService) Print() string {
 	return srv.name
 }
 
-func (srv *SimpleService) Check() error {
-	resp, err := srv.client.Do(srv.request)
+func (srv *SimpleService) Endpoint() string {
+	return srv.endpoint
+}
+
+func (srv *SimpleService) Check(ctx context.Context) error {
+	request, err := srv.newRequest(ctx)
 	if err != nil {
-		return fmt.Errorf("can not make request: %w", err)
+		return err
+	}
+	resp, err := srv.client.Do(request)
+	if err != nil {
+		return classifyError(fmt.Errorf("can not make request: %w", err))
 	}
 	defer func(Body io.ReadCloser) {
 		err := Body.Close()
@@ -44,12 +90,39 @@ func (srv *SimpleService) Check() error {
 			log.Warnf("could not close the response: %s", err.Error())
 		}
 	}(resp.Body)
-	if resp.StatusCode != http.StatusOK {
+	if !srv.options.Statuses.Contains(resp.StatusCode) {
 		return fmt.Errorf("received '%s' from '%s'", resp.Status, srv.endpoint)
 	}
-	_, err = io.Copy(ioutil.Discard, resp.Body)
+	if len(srv.options.Assertions) == 0 && srv.options.MaxBodySize <= 0 {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		if err != nil {
+			return classifyError(fmt.Errorf("could not read response from '%s': %w", srv.endpoint, err))
+		}
+		return nil
+	}
+	return srv.verifyBody(resp.Body)
+}
+
+func (srv *SimpleService) verifyBody(reader io.Reader) error {
+	maxBodySize := srv.options.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(reader, maxBodySize+1))
 	if err != nil {
-		return fmt.Errorf("could not read response from '%s': %w", srv.endpoint, err)
+		return classifyError(fmt.Errorf("could not read response from '%s': %w", srv.endpoint, err))
+	}
+	if int64(len(body)) > maxBodySize {
+		return fmt.Errorf("response from '%s' exceeds %d bytes", srv.endpoint, maxBodySize)
+	}
+	for _, assertion := range srv.options.Assertions {
+		if err := assertion.Assert(body); err != nil {
+			return fmt.Errorf("assertion failed for '%s': %w", srv.endpoint, err)
+		}
 	}
 	return nil
 }
+
+func doNotFollowRedirects(*http.Request, []*http.Request) error {
+	return http.ErrUseLastResponse
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSimpleService_Check_Success(t *testing.T) {
@@ -17,7 +22,7 @@ func TestSimpleService_Check_Success(t *testing.T) {
 	}))
 	defer server.Close()
 	service, err := MakeSimpleService(server.URL, server.Client())
-	err = service.Check()
+	err = service.Check(context.Background())
 	if err != nil {
 		t.Errorf("Unexpected error: '%s'", err.Error())
 	}
@@ -41,7 +46,7 @@ func TestSimpleService_Check_CanNotMakeRequest(t *testing.T) {
 
 func TestSimpleService_Check_NoServer(t *testing.T) {
 	service, err := MakeSimpleService("http://__I_invalid__url", &http.Client{})
-	err = service.Check()
+	err = service.Check(context.Background())
 	if err == nil {
 		t.Errorf("Error is expected when server is not available")
 	}
@@ -57,7 +62,7 @@ func TestSimpleService_Check_Not200(t *testing.T) {
 	}))
 	defer server.Close()
 	service, err := MakeSimpleService(server.URL, server.Client())
-	err = service.Check()
+	err = service.Check(context.Background())
 	if err == nil {
 		t.Errorf("Error is expected when server responds not 200 OK")
 	}
@@ -72,3 +77,140 @@ func TestSimpleService_Print(t *testing.T) {
 		t.Errorf("Unexpected service name: '%s'", service.Print())
 	}
 }
+
+func TestSimpleService_Check_Options(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Unexpected method '%s'", r.Method)
+		}
+		if r.Header.Get("Accept") != "text/plain" {
+			t.Errorf("Unexpected Accept header '%s'", r.Header.Get("Accept"))
+		}
+		if r.Header.Get("X-Probe") != "healthcheck" {
+			t.Errorf("Unexpected X-Probe header '%s'", r.Header.Get("X-Probe"))
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "ping" {
+			t.Errorf("Unexpected body '%s'", string(body))
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	service, err := MakeSimpleServiceWithOptions(server.URL, server.Client(), SimpleServiceOptions{
+		Method:   http.MethodPost,
+		Headers:  map[string]string{"accept": "text/plain", "x-probe": "healthcheck"},
+		Body:     "ping",
+		Statuses: StatusRanges{{From: 200, To: 299}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: '%s'", err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		err = service.Check(context.Background())
+		if err != nil {
+			t.Errorf("Unexpected error: '%s'", err.Error())
+		}
+	}
+}
+
+func TestSimpleService_Check_Redirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(rw, r, "/health", http.StatusMovedPermanently)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	service, _ := MakeSimpleServiceWithOptions(server.URL+"/moved", &http.Client{CheckRedirect: doNotFollowRedirects}, SimpleServiceOptions{
+		Statuses: StatusRanges{{From: 301, To: 301}},
+	})
+	err := service.Check(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: '%s'", err.Error())
+	}
+	following, _ := MakeSimpleServiceWithOptions(server.URL+"/moved", &http.Client{}, SimpleServiceOptions{
+		Statuses: StatusRanges{{From: 301, To: 301}},
+	})
+	err = following.Check(context.Background())
+	if err == nil {
+		t.Errorf("Error is expected when redirect is followed to 200 OK")
+	}
+}
+
+func TestSimpleService_Check_Assertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte(`{"status": "OUT_OF_SERVICE"}`))
+	}))
+	defer server.Close()
+	assertion, _ := MakeBodyAssertion(BodyAssertionDescription{JsonPath: "$.status", Equals: "UP"})
+	service, _ := MakeSimpleServiceWithOptions(server.URL, server.Client(), SimpleServiceOptions{
+		Assertions: []BodyAssertion{assertion},
+	})
+	err := service.Check(context.Background())
+	if err == nil {
+		t.Fatalf("Error is expected when assertion fails")
+	}
+	expected := fmt.Sprintf("assertion failed for '%s': $.status is 'OUT_OF_SERVICE', expected 'UP'", server.URL)
+	if err.Error() != expected {
+		t.Errorf("Unexpected error received: '%s'", err.Error())
+	}
+}
+
+func TestSimpleService_Check_MaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte(`{"status": "UP"}`))
+	}))
+	defer server.Close()
+	service, _ := MakeSimpleServiceWithOptions(server.URL, server.Client(), SimpleServiceOptions{MaxBodySize: 8})
+	err := service.Check(context.Background())
+	if err == nil {
+		t.Fatalf("Error is expected when body is too large")
+	}
+	if err.Error() != fmt.Sprintf("response from '%s' exceeds 8 bytes", server.URL) {
+		t.Errorf("Unexpected error received: '%s'", err.Error())
+	}
+	service, _ = MakeSimpleServiceWithOptions(server.URL, server.Client(), SimpleServiceOptions{MaxBodySize: 16})
+	if err = service.Check(context.Background()); err != nil {
+		t.Errorf("Unexpected error: '%s'", err.Error())
+	}
+}
+
+func TestSimpleService_Check_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	service, _ := MakeSimpleService(server.URL, &http.Client{Timeout: 50 * time.Millisecond})
+	err := service.Check(context.Background())
+	var timeoutError *TimeoutError
+	if !errors.As(err, &timeoutError) {
+		t.Errorf("Timeout error is expected, got '%v'", err)
+	}
+	if !strings.HasPrefix(err.Error(), "timed out: can not make request: ") {
+		t.Errorf("Unexpected error received: '%s'", err.Error())
+	}
+}
+
+func TestSimpleService_Check_Cancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+	service, _ := MakeSimpleService(server.URL, server.Client())
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	err := service.Check(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Cancellation error is expected, got '%v'", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type StatusRange struct {
+	From int
+	To   int
+}
+
+type StatusRanges []StatusRange
+
+var defaultStatusRanges = StatusRanges{{From: 200, To: 200}}
+
+// ParseStatusRanges accepts exact codes ("204"), classes ("2xx") and inclusive ranges ("200-299")
+func ParseStatusRanges(specs []string) (StatusRanges, error) {
+	if len(specs) == 0 {
+		return defaultStatusRanges, nil
+	}
+	var result StatusRanges
+	for _, spec := range specs {
+		statusRange, err := parseStatusRange(strings.TrimSpace(spec))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, statusRange)
+	}
+	return result, nil
+}
+
+func parseStatusRange(spec string) (StatusRange, error) {
+	lowered := strings.ToLower(spec)
+	if len(lowered) == 3 && strings.HasSuffix(lowered, "xx") {
+		class, err := strconv.Atoi(lowered[:1])
+		if err != nil || class < 1 || class > 5 {
+			return StatusRange{}, fmt.Errorf("invalid status class '%s'", spec)
+		}
+		return StatusRange{From: class * 100, To: class*100 + 99}, nil
+	}
+	bounds := strings.SplitN(lowered, "-", 2)
+	from, err := parseStatusCode(bounds[0])
+	if err != nil {
+		return StatusRange{}, fmt.Errorf("invalid status '%s': %w", spec, err)
+	}
+	to := from
+	if len(bounds) == 2 {
+		to, err = parseStatusCode(bounds[1])
+		if err != nil {
+			return StatusRange{}, fmt.Errorf("invalid status '%s': %w", spec, err)
+		}
+	}
+	if from > to {
+		return StatusRange{}, fmt.Errorf("invalid status range '%s': lower bound is greater than upper", spec)
+	}
+	return StatusRange{From: from, To: to}, nil
+}
+
+func parseStatusCode(code string) (int, error) {
+	status, err := strconv.Atoi(strings.TrimSpace(code))
+	if err != nil {
+		return 0, err
+	}
+	if status < 100 || status > 599 {
+		return 0, fmt.Errorf("status code %d is out of range", status)
+	}
+	return status, nil
+}
+
+func (ranges StatusRanges) Contains(status int) bool {
+	for _, statusRange := range ranges {
+		if statusRange.From <= status && status <= statusRange.To {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseStatusRanges_Default(t *testing.T) {
+	ranges, err := ParseStatusRanges(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if !ranges.Contains(200) || ranges.Contains(204) {
+		t.Errorf("Unexpected default ranges: %v", ranges)
+	}
+}
+
+func TestParseStatusRanges(t *testing.T) {
+	ranges, err := ParseStatusRanges([]string{"2xx", "301", "400-404"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	for _, status := range []int{200, 204, 299, 301, 400, 404} {
+		if !ranges.Contains(status) {
+			t.Errorf("Status %d should be accepted", status)
+		}
+	}
+	for _, status := range []int{199, 300, 302, 405, 500} {
+		if ranges.Contains(status) {
+			t.Errorf("Status %d should not be accepted", status)
+		}
+	}
+}
+
+func TestParseStatusRanges_Invalid(t *testing.T) {
+	for _, spec := range []string{"abc", "9xx", "600", "299-200", "200-", "x"} {
+		_, err := ParseStatusRanges([]string{spec})
+		if err == nil {
+			t.Errorf("Error is expected for '%s'", spec)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBannerTimeout = 5 * time.Second
+	bannerMaxSize        = 4096
+)
+
+type TcpService struct {
+	address string
+	expect  string
+	dialer  *net.Dialer
+	timeout time.Duration
+	name    string
+}
+
+// MakeTcpService makes a check, which is limited by timeout as a whole, zero means that only the banner read is limited
+func MakeTcpService(address string, expect string, dialer *net.Dialer, timeout time.Duration) Service {
+	return &TcpService{
+		address: address,
+		expect:  expect,
+		dialer:  dialer,
+		timeout: timeout,
+		name:    fmt.Sprintf("tcp service at '%s'", address),
+	}
+}
+
+func (srv *TcpService) Print() string {
+	return srv.name
+}
+
+func (srv *TcpService) Endpoint() string {
+	return srv.address
+}
+
+func (srv *TcpService) Check(ctx context.Context) error {
+	deadline := time.Now().Add(defaultBannerTimeout)
+	if srv.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, srv.timeout)
+		defer cancel()
+	}
+	if ctxDeadline, ok := ctx.Deadline(); ok {
+		deadline = ctxDeadline
+	}
+	conn, err := srv.dialer.DialContext(ctx, "tcp", srv.address)
+	if err != nil {
+		return classifyError(fmt.Errorf("can not connect: %w", err))
+	}
+	defer func(conn net.Conn) {
+		err := conn.Close()
+		if err != nil {
+			log.Warnf("could not close the connection: %s", err.Error())
+		}
+	}(conn)
+	if srv.expect == "" {
+		return nil
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	return srv.awaitBanner(conn, deadline)
+}
+
+func (srv *TcpService) awaitBanner(conn net.Conn, deadline time.Time) error {
+	err := conn.SetReadDeadline(deadline)
+	if err != nil {
+		return fmt.Errorf("can not set read deadline: %w", err)
+	}
+	banner := make([]byte, 0, bannerMaxSize)
+	buffer := make([]byte, bannerMaxSize)
+	for len(banner) < bannerMaxSize {
+		n, err := conn.Read(buffer[:bannerMaxSize-len(banner)])
+		banner = append(banner, buffer[:n]...)
+		if strings.Contains(string(banner), srv.expect) {
+			return nil
+		}
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return &TimeoutError{Err: fmt.Errorf("waiting for '%s' from '%s': %w", srv.expect, srv.address, err)}
+			}
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("could not read banner from '%s': %w", srv.address, err)
+		}
+	}
+	return fmt.Errorf("banner from '%s' does not contain '%s'", srv.address, srv.expect)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func listenTcp(t *testing.T, banner string) net.Listener {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error on listening: %s", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte(banner))
+			_ = conn.Close()
+		}
+	}()
+	return listener
+}
+
+func TestTcpService_Check_Success(t *testing.T) {
+	listener := listenTcp(t, "")
+	defer listener.Close()
+	service := MakeTcpService(listener.Addr().String(), "", &net.Dialer{}, 0)
+	err := service.Check(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: '%s'", err.Error())
+	}
+}
+
+func TestTcpService_Check_BannerMatches(t *testing.T) {
+	listener := listenTcp(t, "+OK redis ready\r\n")
+	defer listener.Close()
+	service := MakeTcpService(listener.Addr().String(), "+OK", &net.Dialer{}, 0)
+	err := service.Check(context.Background())
+	if err != nil {
+		t.Errorf("Unexpected error: '%s'", err.Error())
+	}
+}
+
+func TestTcpService_Check_BannerDoesNotMatch(t *testing.T) {
+	listener := listenTcp(t, "-ERR\r\n")
+	defer listener.Close()
+	address := listener.Addr().String()
+	service := MakeTcpService(address, "+OK", &net.Dialer{}, 0)
+	err := service.Check(context.Background())
+	if err == nil {
+		t.Fatalf("Error is expected when banner does not match")
+	}
+	if err.Error() != "banner from '"+address+"' does not contain '+OK'" {
+		t.Errorf("Unexpected error received: '%s'", err.Error())
+	}
+}
+
+func TestTcpService_Check_NoServer(t *testing.T) {
+	listener := listenTcp(t, "")
+	address := listener.Addr().String()
+	_ = listener.Close()
+	service := MakeTcpService(address, "", &net.Dialer{}, 0)
+	err := service.Check(context.Background())
+	if err == nil {
+		t.Fatalf("Error is expected when server is not available")
+	}
+	if !strings.HasPrefix(err.Error(), "can not connect: ") {
+		t.Errorf("Unexpected error received: '%s'", err.Error())
+	}
+}
+
+func TestTcpService_Print(t *testing.T) {
+	service := MakeTcpService("postgres:5432", "", &net.Dialer{}, 0)
+	if service.Print() != "tcp service at 'postgres:5432'" {
+		t.Errorf("Unexpected service name: '%s'", service.Print())
+	}
+}
+
+func TestTcpService_Check_BannerTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error on listening: %s", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+	service := MakeTcpService(listener.Addr().String(), "+OK", &net.Dialer{}, 100*time.Millisecond)
+	err = service.Check(context.Background())
+	var timeoutError *TimeoutError
+	if !errors.As(err, &timeoutError) {
+		t.Errorf("Timeout error is expected, got '%v'", err)
+	}
+}
+
+func TestTcpService_Check_Cancelled(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error on listening: %s", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+	service := MakeTcpService(listener.Addr().String(), "+OK", &net.Dialer{}, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	started := time.Now()
+	err = service.Check(ctx)
+	if err == nil {
+		t.Errorf("Error is expected when the check is cancelled")
+	}
+	if time.Since(started) > 500*time.Millisecond {
+		t.Errorf("Check should stop waiting for the banner after cancellation")
+	}
+}
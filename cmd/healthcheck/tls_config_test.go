@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	stdlog "log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCertificate(t *testing.T, dir string, name string, der []byte, key *ecdsa.PrivateKey) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	return certFile, keyFile
+}
+
+func makeSelfSignedCertificate(t *testing.T, dir string, name string, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	return writeCertificate(t, dir, name, der, key)
+}
+
+func writeServerCa(t *testing.T, server *httptest.Server) string {
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	certificate := server.Certificate()
+	if err := ioutil.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	return caFile
+}
+
+func checkTls(t *testing.T, endpoint string, config TlsConfig) error {
+	tlsConfig, err := MakeTlsConfig(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	client := MakeHttpClientFactory(HttpClientConfig{}).MakeTlsClient(TimeoutsConfig{}, tlsConfig)
+	service, err := MakeSimpleService(endpoint, client)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	return service.Check(context.Background())
+}
+
+func TestMakeTlsConfig_Empty(t *testing.T) {
+	tlsConfig, err := MakeTlsConfig(TlsConfig{})
+	if err != nil || tlsConfig != nil {
+		t.Errorf("Unexpected tls configuration %v, error %v", tlsConfig, err)
+	}
+}
+
+func TestMakeTlsConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, _ := makeSelfSignedCertificate(t, dir, "client", time.Now().Add(time.Hour))
+	garbage := filepath.Join(dir, "garbage.pem")
+	_ = ioutil.WriteFile(garbage, []byte("garbage"), 0600)
+	for _, config := range []TlsConfig{
+		{CaFile: filepath.Join(dir, "missing.pem")},
+		{CaFile: garbage},
+		{CertFile: certFile},
+		{CertFile: certFile, KeyFile: garbage},
+	} {
+		if _, err := MakeTlsConfig(config); err == nil {
+			t.Errorf("Unexpected nil error for %+v", config)
+		}
+	}
+}
+
+func TestSimpleService_Check_Tls(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caFile := writeServerCa(t, server)
+
+	var certErr *CertificateError
+	if err := checkTls(t, server.URL, TlsConfig{}); !errors.As(err, &certErr) {
+		t.Errorf("Unexpected error for an unknown authority: %v", err)
+	}
+	if err := checkTls(t, server.URL, TlsConfig{CaFile: caFile}); err != nil {
+		t.Errorf("Unexpected error with the ca bundle: %s", err.Error())
+	}
+	if err := checkTls(t, server.URL, TlsConfig{InsecureSkipVerify: true}); err != nil {
+		t.Errorf("Unexpected error with insecure-skip-verify: %s", err.Error())
+	}
+	if err := checkTls(t, server.URL, TlsConfig{CaFile: caFile, ServerName: "example.com"}); err != nil {
+		t.Errorf("Unexpected error with a valid server name: %s", err.Error())
+	}
+	if err := checkTls(t, server.URL, TlsConfig{CaFile: caFile, ServerName: "other.local"}); !errors.As(err, &certErr) {
+		t.Errorf("Unexpected error for an invalid server name: %v", err)
+	}
+}
+
+func TestSimpleService_Check_Plaintext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	err := checkTls(t, strings.Replace(server.URL, "http://", "https://", 1), TlsConfig{})
+	var plaintextErr *PlaintextError
+	var certErr *CertificateError
+	if !errors.As(err, &plaintextErr) || errors.As(err, &certErr) {
+		t.Errorf("Unexpected error for a plain http server: %v", err)
+	}
+}
+
+func TestSimpleService_Check_MutualTls(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := makeSelfSignedCertificate(t, dir, "client", time.Now().Add(time.Hour))
+	clientCa, _ := ioutil.ReadFile(certFile)
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(clientCa)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.Config.ErrorLog = stdlog.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+	caFile := writeServerCa(t, server)
+
+	var certErr *CertificateError
+	if err := checkTls(t, server.URL, TlsConfig{CaFile: caFile}); !errors.As(err, &certErr) {
+		t.Errorf("Unexpected error without a client certificate: %v", err)
+	}
+	if err := checkTls(t, server.URL, TlsConfig{CaFile: caFile, CertFile: certFile, KeyFile: keyFile}); err != nil {
+		t.Errorf("Unexpected error with a client certificate: %s", err.Error())
+	}
+}
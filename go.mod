@@ -3,6 +3,7 @@ module healthcheck
 go 1.16
 
 require (
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/go-co-op/gocron v1.13.0
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/viper v1.10.1